@@ -16,10 +16,12 @@ package main
 
 import (
 	"flag"
+	"os"
 	"time"
 
 	csicontrollerdriver "github.com/oracle/oci-cloud-controller-manager/cmd/oci-csi-controller-driver/csi-controller-driver"
 	"github.com/oracle/oci-cloud-controller-manager/cmd/oci-csi-controller-driver/csioptions"
+	csi_util "github.com/oracle/oci-cloud-controller-manager/pkg/csi-util"
 	"github.com/oracle/oci-cloud-controller-manager/pkg/csi/driver"
 	"github.com/oracle/oci-cloud-controller-manager/pkg/logging"
 	"github.com/oracle/oci-cloud-controller-manager/pkg/util/signals"
@@ -29,6 +31,10 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// maximumVolumeSizeEnvVar optionally overrides the maximum size of a block
+// volume, e.g. 64Ti, for regions where OCI supports larger volumes.
+const maximumVolumeSizeEnvVar = "MAXIMUM_VOLUME_SIZE"
+
 func main() {
 	csiOptions := csioptions.CSIOptions{}
 	flag.StringVar(&csiOptions.Endpoint, "endpoint", "unix://tmp/csi.sock", "CSI endpoint")
@@ -39,6 +45,9 @@ func main() {
 	stopCh := signals.SetupSignalHandler()
 	log := logging.Logger()
 	logger := log.Sugar()
+	if err := setMaximumVolumeSize(os.Getenv(maximumVolumeSizeEnvVar)); err != nil {
+		logger.With(zap.Error(err)).Fatalf("Invalid %s.", maximumVolumeSizeEnvVar)
+	}
 	config, err := clientcmd.BuildConfigFromFlags(csiOptions.Master, csiOptions.Kubeconfig)
 	clientset, err := kubernetes.NewForConfig(config)
 	err = wait.PollUntil(15*time.Second, func() (done bool, err error) {
@@ -65,3 +74,16 @@ func main() {
 	go csicontrollerdriver.StartControllerDriver(csiOptions, driver.FSS)
 	<-stopCh
 }
+
+// setMaximumVolumeSize applies the given maximum volume size override, e.g.
+// 64Ti. An empty value keeps the default maximum.
+func setMaximumVolumeSize(value string) error {
+	if value == "" {
+		return nil
+	}
+	bytes, err := csi_util.ParseBytes(value)
+	if err != nil {
+		return err
+	}
+	return csi_util.SetMaximumVolumeSize(bytes)
+}
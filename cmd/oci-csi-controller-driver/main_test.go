@@ -0,0 +1,69 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	csi_util "github.com/oracle/oci-cloud-controller-manager/pkg/csi-util"
+	"github.com/oracle/oci-cloud-controller-manager/pkg/oci/client"
+)
+
+func Test_setMaximumVolumeSize(t *testing.T) {
+	defer csi_util.SetMaximumVolumeSize(csi_util.MaximumVolumeSizeInBytes)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{
+			name:  "Unset keeps the default",
+			value: "",
+			want:  csi_util.MaximumVolumeSizeInBytes,
+		},
+		{
+			name:  "Larger maximum",
+			value: "64Ti",
+			want:  64 * client.TiB,
+		},
+		{
+			name:    "Unparsable size",
+			value:   "lots",
+			wantErr: true,
+		},
+		{
+			name:    "Above the absolute ceiling",
+			value:   "2Pi",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csi_util.SetMaximumVolumeSize(csi_util.MaximumVolumeSizeInBytes)
+			err := setMaximumVolumeSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("setMaximumVolumeSize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				if got := csi_util.GetMaximumVolumeSize(); got != tt.want {
+					t.Errorf("GetMaximumVolumeSize() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -18,104 +18,201 @@ import (
 	"flag"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"k8s.io/klog"
 
 	"github.com/oracle/oci-cloud-controller-manager/cmd/oci-csi-node-driver/nodedriver"
 	"github.com/oracle/oci-cloud-controller-manager/cmd/oci-csi-node-driver/nodedriveroptions"
+	csi_util "github.com/oracle/oci-cloud-controller-manager/pkg/csi-util"
 	"github.com/oracle/oci-cloud-controller-manager/pkg/csi/driver"
+	"github.com/oracle/oci-cloud-controller-manager/pkg/logging"
 	"github.com/oracle/oci-cloud-controller-manager/pkg/util/signals"
 )
 
+// driverSocketReadyTimeout bounds each wait for all enabled driver sockets to
+// accept connections, after which the wait is logged and retried.
+const driverSocketReadyTimeout = 2 * time.Minute
+
+// defaultReadyFile is created once every enabled driver socket accepts
+// connections. The pod readiness probe checks for it.
+const defaultReadyFile = "/tmp/csi-node-driver-ready"
+
+// iscsiIpv6PrefixEnvVar optionally overrides the prefix used to build IPv6
+// iSCSI addresses, for realms whose iSCSI range differs from the default.
+const iscsiIpv6PrefixEnvVar = "ISCSI_IPV6_PREFIX"
+
+// defaultKubeletRoot is the kubelet root directory on the host, under which
+// the node-driver-registrar registers the driver sockets by default.
+const defaultKubeletRoot = "/var/lib/kubelet"
+
 func main() {
 	nodecsioptions := nodedriveroptions.NodeCSIOptions{}
 
-	flag.StringVar(&nodecsioptions.Endpoint, "endpoint", "unix://tmp/csi.sock", "Block Volume CSI endpoint")
+	flag.StringVar(&nodecsioptions.Endpoint, "endpoint", driver.DefaultEndpointForDriver(driver.BlockVolumeDriverName), "Block Volume CSI endpoint")
+	flag.StringVar(&nodecsioptions.KubeletRegistrationPath, "kubelet-registration-path", csi_util.RegistrationSocketPath(defaultKubeletRoot, driver.BlockVolumeDriverName), "Path of the Block Volume CSI driver socket on the Kubernetes host machine, used to validate the endpoint.")
+	flag.BoolVar(&nodecsioptions.EnableBvDriver, "bv-csi-driver-enabled", true, "Handle flag to enable Block Volume CSI driver")
 	flag.StringVar(&nodecsioptions.NodeID, "nodeid", "", "node id")
 	flag.StringVar(&nodecsioptions.LogLevel, "loglevel", "info", "log level")
 	flag.StringVar(&nodecsioptions.Master, "master", "", "kube master")
 	flag.StringVar(&nodecsioptions.Kubeconfig, "kubeconfig", "", "cluster kubeconfig")
-	flag.StringVar(&nodecsioptions.FssEndpoint, "fss-endpoint", "unix://tmp/fss/csi.sock", "FSS CSI endpoint")
+	flag.StringVar(&nodecsioptions.FssEndpoint, "fss-endpoint", driver.DefaultEndpointForDriver(driver.FSSDriverName), "FSS CSI endpoint")
+	flag.StringVar(&nodecsioptions.FssKubeletRegistrationPath, "fss-kubelet-registration-path", csi_util.RegistrationSocketPath(defaultKubeletRoot, driver.FSSDriverName), "Path of the FSS CSI driver socket on the Kubernetes host machine, used to validate the endpoint.")
 	flag.BoolVar(&nodecsioptions.EnableFssDriver, "fss-csi-driver-enabled", true, "Handle flag to enable FSS CSI driver")
-	flag.StringVar(&nodecsioptions.LustreEndpoint, "lustre-endpoint", "unix:///lustre/csi.sock", "Lustre CSI endpoint")
+	flag.StringVar(&nodecsioptions.LustreEndpoint, "lustre-endpoint", driver.DefaultEndpointForDriver(driver.LustreDriverName), "Lustre CSI endpoint")
 	flag.StringVar(&nodecsioptions.LustreCsiAddress, "lustre-csi-address", "/lustre/csi.sock", "Path of the Lustre CSI driver socket that the node-driver-registrar will connect to.")
-	flag.StringVar(&nodecsioptions.LustreKubeletRegistrationPath, "lustre-kubelet-registration-path", "/var/lib/kubelet/plugins/lustre.csi.oraclecloud.com/csi.sock", "Path of the Lustre CSI driver socket on the Kubernetes host machine.")
+	flag.StringVar(&nodecsioptions.LustreKubeletRegistrationPath, "lustre-kubelet-registration-path", csi_util.RegistrationSocketPath(defaultKubeletRoot, driver.LustreDriverName), "Path of the Lustre CSI driver socket on the Kubernetes host machine, used to validate the endpoint.")
+	flag.StringVar(&nodecsioptions.ReadyFile, "ready-file", defaultReadyFile, "File created once all enabled CSI driver sockets accept connections, for the pod readiness probe.")
 
 	klog.InitFlags(nil)
 	flag.Set("logtostderr", "true")
 	flag.Parse()
 
-	viper.Set("log-level", getLevel(nodecsioptions.LogLevel))
+	viper.Set(logging.LogLevelKey, getLevel(nodecsioptions.LogLevel))
+
+	logger := logging.Logger().Sugar()
+	validateLogLevel(logger, nodecsioptions.LogLevel)
+
+	if err := csi_util.SetIscsiIpv6Prefix(os.Getenv(iscsiIpv6PrefixEnvVar)); err != nil {
+		logger.With(zap.Error(err)).Fatalf("Invalid %s.", iscsiIpv6PrefixEnvVar)
+	}
 
 	enableLustreDriver := IsLustreDriverEnabled()
 
 	blockvolumeNodeOptions := nodedriveroptions.NodeOptions{
-		Name:                   "BV",
-		Endpoint:               nodecsioptions.Endpoint,
-		NodeID:                 nodecsioptions.NodeID,
-		Kubeconfig:             nodecsioptions.Kubeconfig,
-		Master:                 nodecsioptions.Master,
-		DriverName:             driver.BlockVolumeDriverName,
-		DriverVersion:          driver.BlockVolumeDriverVersion,
-		EnableControllerServer: false,
+		Name:                    "BV",
+		Endpoint:                nodecsioptions.Endpoint,
+		KubeletRegistrationPath: nodecsioptions.KubeletRegistrationPath,
+		NodeID:                  nodecsioptions.NodeID,
+		Kubeconfig:              nodecsioptions.Kubeconfig,
+		Master:                  nodecsioptions.Master,
+		DriverName:              driver.BlockVolumeDriverName,
+		DriverVersion:           driver.BlockVolumeDriverVersion,
+		EnableControllerServer:  false,
 	}
 	fssNodeOptions := nodedriveroptions.NodeOptions{
-		Name:                   "FSS",
-		Endpoint:               nodecsioptions.FssEndpoint,
-		NodeID:                 nodecsioptions.NodeID,
-		Kubeconfig:             nodecsioptions.Kubeconfig,
-		Master:                 nodecsioptions.Master,
-		DriverName:             driver.FSSDriverName,
-		DriverVersion:          driver.FSSDriverVersion,
-		EnableControllerServer: false,
+		Name:                    "FSS",
+		Endpoint:                nodecsioptions.FssEndpoint,
+		KubeletRegistrationPath: nodecsioptions.FssKubeletRegistrationPath,
+		NodeID:                  nodecsioptions.NodeID,
+		Kubeconfig:              nodecsioptions.Kubeconfig,
+		Master:                  nodecsioptions.Master,
+		DriverName:              driver.FSSDriverName,
+		DriverVersion:           driver.FSSDriverVersion,
+		EnableControllerServer:  false,
 	}
 
 	lustreNodeOptions := nodedriveroptions.NodeOptions{
-		Name:                   "Lustre",
-		Endpoint:               nodecsioptions.LustreEndpoint,
-		NodeID:                 nodecsioptions.NodeID,
-		Kubeconfig:             nodecsioptions.Kubeconfig,
-		Master:                 nodecsioptions.Master,
-		DriverName:             driver.LustreDriverName,
-		DriverVersion:          driver.LustreDriverVersion,
-		EnableControllerServer: false,
+		Name:                    "Lustre",
+		Endpoint:                nodecsioptions.LustreEndpoint,
+		KubeletRegistrationPath: nodecsioptions.LustreKubeletRegistrationPath,
+		NodeID:                  nodecsioptions.NodeID,
+		Kubeconfig:              nodecsioptions.Kubeconfig,
+		Master:                  nodecsioptions.Master,
+		DriverName:              driver.LustreDriverName,
+		DriverVersion:           driver.LustreDriverVersion,
+		EnableControllerServer:  false,
 	}
 
-	stopCh := signals.SetupSignalHandler()
-
-	go nodedriver.RunNodeDriver(blockvolumeNodeOptions, stopCh)
+	var enabledNodeOptions []nodedriveroptions.NodeOptions
+	if nodecsioptions.EnableBvDriver {
+		enabledNodeOptions = append(enabledNodeOptions, blockvolumeNodeOptions)
+	}
 	if nodecsioptions.EnableFssDriver {
-		go nodedriver.RunNodeDriver(fssNodeOptions, stopCh)
+		enabledNodeOptions = append(enabledNodeOptions, fssNodeOptions)
 	}
 	if enableLustreDriver {
-		go nodedriver.RunNodeDriver(lustreNodeOptions, stopCh)
+		enabledNodeOptions = append(enabledNodeOptions, lustreNodeOptions)
+	}
+
+	if len(enabledNodeOptions) == 0 {
+		logger.Fatal("No CSI node driver is enabled.")
+	}
+	if err := validateDriverSocketConfigs(enabledNodeOptions); err != nil {
+		logger.With(zap.Error(err)).Fatal("Invalid CSI driver socket configuration.")
+	}
+
+	stopCh := signals.SetupSignalHandler()
+
+	var endpoints []string
+	for _, nodeOptions := range enabledNodeOptions {
+		go nodedriver.RunNodeDriver(nodeOptions, stopCh)
+		endpoints = append(endpoints, nodeOptions.Endpoint)
 	}
+	go markReadyWhenSocketsListen(logger, endpoints, nodecsioptions.ReadyFile, driverSocketReadyTimeout, stopCh)
 	<-stopCh
 }
 
+// markReadyWhenSocketsListen creates readyFile once every endpoint accepts
+// connections, so that the pod only becomes ready when all enabled drivers
+// are serving. A driver that is slow to come up keeps the pod unready without
+// stopping the drivers that are already serving.
+func markReadyWhenSocketsListen(logger *zap.SugaredLogger, endpoints []string, readyFile string, timeout time.Duration, stopCh <-chan struct{}) {
+	if err := os.Remove(readyFile); err != nil && !os.IsNotExist(err) {
+		logger.With(zap.Error(err), "readyFile", readyFile).Error("Failed to remove stale ready file.")
+		return
+	}
+	for {
+		err := csi_util.WaitForDriverSockets(endpoints, timeout)
+		if err == nil {
+			break
+		}
+		logger.With(zap.Error(err), "endpoints", endpoints).Error("CSI driver sockets are not ready yet.")
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+	}
+	if err := os.WriteFile(readyFile, nil, 0644); err != nil {
+		logger.With(zap.Error(err), "readyFile", readyFile).Error("Failed to create ready file.")
+		return
+	}
+	logger.With("endpoints", endpoints, "readyFile", readyFile).Info("All CSI driver sockets are ready.")
+}
+
+// validateDriverSocketConfigs checks that the endpoint and kubelet
+// registration path of every given driver refer to the same socket.
+func validateDriverSocketConfigs(nodeOptions []nodedriveroptions.NodeOptions) error {
+	for _, options := range nodeOptions {
+		if err := csi_util.ValidateDriverSocketConfig(options.DriverName, options.Endpoint, options.KubeletRegistrationPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func IsLustreDriverEnabled() bool {
 	return strings.EqualFold(os.Getenv("LUSTRE_DRIVER_ENABLED"), "true")
 }
 
+// knownLogLevels maps the accepted values of the loglevel flag to zap levels.
+var knownLogLevels = map[string]zapcore.Level{
+	"debug":  zapcore.DebugLevel,
+	"info":   zapcore.InfoLevel,
+	"warn":   zapcore.WarnLevel,
+	"error":  zapcore.ErrorLevel,
+	"dpanic": zapcore.DPanicLevel,
+	"panic":  zapcore.PanicLevel,
+	"fatal":  zapcore.FatalLevel,
+}
+
 func getLevel(loglevel string) int8 {
-	switch loglevel {
-	case "debug":
-		return int8(zapcore.DebugLevel)
-	case "info":
-		return int8(zapcore.InfoLevel)
-	case "warn":
-		return int8(zapcore.WarnLevel)
-	case "error":
-		return int8(zapcore.ErrorLevel)
-	case "dpanic":
-		return int8(zapcore.DPanicLevel)
-	case "panic":
-		return int8(zapcore.PanicLevel)
-	case "fatal":
-		return int8(zapcore.FatalLevel)
-	default:
-		return int8(zapcore.InfoLevel)
+	if level, ok := knownLogLevels[loglevel]; ok {
+		return int8(level)
+	}
+	return int8(zapcore.InfoLevel)
+}
+
+// validateLogLevel warns when loglevel is not one of the known levels and
+// getLevel would silently fall back to info. It reports whether the level is known.
+func validateLogLevel(logger *zap.SugaredLogger, loglevel string) bool {
+	if _, ok := knownLogLevels[loglevel]; ok {
+		return true
 	}
+	logger.With("loglevel", loglevel).Warn("Unrecognized log level, defaulting to info.")
+	return false
 }
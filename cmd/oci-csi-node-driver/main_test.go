@@ -15,8 +15,20 @@
 package main
 
 import (
+	"bytes"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/oracle/oci-cloud-controller-manager/cmd/oci-csi-node-driver/nodedriveroptions"
+	csi_util "github.com/oracle/oci-cloud-controller-manager/pkg/csi-util"
+	"github.com/oracle/oci-cloud-controller-manager/pkg/csi/driver"
 )
 
 func Test_IsLustreDriverEnabled(t *testing.T) {
@@ -49,3 +61,170 @@ func Test_IsLustreDriverEnabled(t *testing.T) {
 		}
 	}
 }
+
+func Test_validateLogLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		loglevel string
+		want     bool
+		wantWarn bool
+	}{
+		{
+			name:     "Valid level",
+			loglevel: "debug",
+			want:     true,
+		},
+		{
+			name:     "Unknown level",
+			loglevel: "infoo",
+			want:     false,
+			wantWarn: true,
+		},
+		{
+			name:     "Empty level",
+			loglevel: "",
+			want:     false,
+			wantWarn: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			logger := zap.New(zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), zapcore.AddSync(buf), zapcore.DebugLevel)).Sugar()
+			if got := validateLogLevel(logger, tt.loglevel); got != tt.want {
+				t.Errorf("validateLogLevel() = %v, want %v", got, tt.want)
+			}
+			if gotWarn := strings.Contains(buf.String(), "Unrecognized log level"); gotWarn != tt.wantWarn {
+				t.Errorf("validateLogLevel() warned = %v, want %v", gotWarn, tt.wantWarn)
+			}
+			if got := getLevel(tt.loglevel); !tt.want && got != int8(zapcore.InfoLevel) {
+				t.Errorf("getLevel() = %v, want %v", got, int8(zapcore.InfoLevel))
+			}
+		})
+	}
+}
+
+func Test_validateDriverSocketConfigs(t *testing.T) {
+	lustreOptions := func(endpoint, registrationPath string) nodedriveroptions.NodeOptions {
+		return nodedriveroptions.NodeOptions{
+			Name:                    "Lustre",
+			Endpoint:                endpoint,
+			KubeletRegistrationPath: registrationPath,
+			DriverName:              driver.LustreDriverName,
+		}
+	}
+	bvOptions := nodedriveroptions.NodeOptions{
+		Name:                    "BV",
+		Endpoint:                driver.DefaultEndpointForDriver(driver.BlockVolumeDriverName),
+		KubeletRegistrationPath: csi_util.RegistrationSocketPath(defaultKubeletRoot, driver.BlockVolumeDriverName),
+		DriverName:              driver.BlockVolumeDriverName,
+	}
+	fssOptions := nodedriveroptions.NodeOptions{
+		Name:                    "FSS",
+		Endpoint:                driver.DefaultEndpointForDriver(driver.FSSDriverName),
+		KubeletRegistrationPath: csi_util.RegistrationSocketPath(defaultKubeletRoot, driver.FSSDriverName),
+		DriverName:              driver.FSSDriverName,
+	}
+
+	tests := []struct {
+		name        string
+		nodeOptions []nodedriveroptions.NodeOptions
+		wantErr     bool
+	}{
+		{
+			name: "Defaults of all drivers",
+			nodeOptions: []nodedriveroptions.NodeOptions{
+				bvOptions,
+				fssOptions,
+				lustreOptions(driver.DefaultEndpointForDriver(driver.LustreDriverName), csi_util.RegistrationSocketPath(defaultKubeletRoot, driver.LustreDriverName)),
+			},
+		},
+		{
+			name: "Lustre registration socket name mismatch",
+			nodeOptions: []nodedriveroptions.NodeOptions{
+				bvOptions,
+				lustreOptions(driver.DefaultEndpointForDriver(driver.LustreDriverName), "/var/lib/kubelet/plugins/lustre.csi.oraclecloud.com/lustre.sock"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "BV registration path of another driver",
+			nodeOptions: []nodedriveroptions.NodeOptions{
+				{
+					Name:                    "BV",
+					Endpoint:                driver.DefaultEndpointForDriver(driver.BlockVolumeDriverName),
+					KubeletRegistrationPath: csi_util.RegistrationSocketPath(defaultKubeletRoot, driver.FSSDriverName),
+					DriverName:              driver.BlockVolumeDriverName,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "FSS registration socket name mismatch",
+			nodeOptions: []nodedriveroptions.NodeOptions{
+				bvOptions,
+				{
+					Name:                    "FSS",
+					Endpoint:                driver.DefaultEndpointForDriver(driver.FSSDriverName),
+					KubeletRegistrationPath: "/var/lib/kubelet/plugins/fss.csi.oraclecloud.com/fss.sock",
+					DriverName:              driver.FSSDriverName,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Lustre registration path of another driver",
+			nodeOptions: []nodedriveroptions.NodeOptions{
+				lustreOptions(driver.DefaultEndpointForDriver(driver.LustreDriverName), csi_util.RegistrationSocketPath(defaultKubeletRoot, driver.FSSDriverName)),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateDriverSocketConfigs(tt.nodeOptions); (err != nil) != tt.wantErr {
+				t.Errorf("validateDriverSocketConfigs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_markReadyWhenSocketsListen(t *testing.T) {
+	dir := t.TempDir()
+	listening := filepath.Join(dir, "listening.sock")
+	listener, err := net.Listen("unix", listening)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", listening, err)
+	}
+	defer listener.Close()
+
+	tests := []struct {
+		name      string
+		endpoints []string
+		wantReady bool
+	}{
+		{
+			name:      "All sockets listening",
+			endpoints: []string{"unix://" + listening},
+			wantReady: true,
+		},
+		{
+			name:      "A socket never comes up",
+			endpoints: []string{"unix://" + listening, "unix://" + filepath.Join(dir, "missing.sock")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readyFile := filepath.Join(t.TempDir(), "ready")
+			stopCh := make(chan struct{})
+			close(stopCh)
+
+			markReadyWhenSocketsListen(zap.S(), tt.endpoints, readyFile, 200*time.Millisecond, stopCh)
+
+			_, err := os.Stat(readyFile)
+			if ready := err == nil; ready != tt.wantReady {
+				t.Errorf("markReadyWhenSocketsListen() created ready file = %v, want %v", ready, tt.wantReady)
+			}
+		})
+	}
+}
@@ -14,28 +14,34 @@
 
 package nodedriveroptions
 
-//NodeCSIOptions contains details about the flag
+// NodeCSIOptions contains details about the flag
 type NodeCSIOptions struct {
-	Endpoint   string // Used for Block Volume CSI driver
-	NodeID     string
-	LogLevel   string
-	Master     string
-	Kubeconfig string
+	Endpoint                string // Used for Block Volume CSI driver
+	KubeletRegistrationPath string // Used for Block Volume CSI driver
+	NodeID                  string
+	LogLevel                string
+	Master                  string
+	Kubeconfig              string
+	EnableBvDriver          bool
 
-	EnableFssDriver            bool
-	FssEndpoint                string
+	EnableFssDriver               bool
+	FssEndpoint                   string
+	FssKubeletRegistrationPath    string
 	LustreCsiAddress              string
 	LustreKubeletRegistrationPath string
 	LustreEndpoint                string
+
+	ReadyFile string // Created once all enabled driver sockets accept connections
 }
 
 type NodeOptions struct {
-	Name                   string
-	Endpoint               string
-	NodeID                 string
-	Kubeconfig             string
-	Master                 string
-	DriverName             string
-	DriverVersion          string
-	EnableControllerServer bool
+	Name                    string
+	Endpoint                string
+	KubeletRegistrationPath string
+	NodeID                  string
+	Kubeconfig              string
+	Master                  string
+	DriverName              string
+	DriverVersion           string
+	EnableControllerServer  bool
 }
@@ -0,0 +1,113 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	utilexec "k8s.io/utils/exec"
+)
+
+// blkidSignature is the on-disk signature of a device as reported by blkid.
+type blkidSignature struct {
+	// FsType is the filesystem type, e.g. ext4.
+	FsType string
+	// PtType is the partition table type, e.g. gpt.
+	PtType string
+}
+
+// unformatted returns whether no filesystem or partition table was found.
+func (s blkidSignature) unformatted() bool {
+	return s.FsType == "" && s.PtType == ""
+}
+
+// NeedsFormat returns whether the device holds neither a filesystem nor a
+// partition table and can therefore be formatted without losing data.
+func NeedsFormat(logger *zap.SugaredLogger, devicePath string) (bool, error) {
+	return needsFormat(logger, NewCommandRunner(), devicePath)
+}
+
+func needsFormat(logger *zap.SugaredLogger, runner CommandRunner, devicePath string) (bool, error) {
+	signature, err := probeBlkidSignature(runner, devicePath)
+	if err != nil {
+		return false, err
+	}
+	logger.With("devicePath", devicePath, "fsType", signature.FsType, "ptType", signature.PtType).Info("Probed device signature.")
+	return signature.unformatted(), nil
+}
+
+// probeBlkidSignature reads the filesystem and partition table type of the
+// device. blkid exits with status 2 when it finds no signature, which is
+// reported as an empty blkidSignature.
+func probeBlkidSignature(runner CommandRunner, devicePath string) (blkidSignature, error) {
+	output, err := runner.Run("blkid", "-p", "-s", "TYPE", "-s", "PTTYPE", "-o", "export", devicePath)
+	if err != nil {
+		var exitErr utilexec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitStatus() == 2 {
+			return blkidSignature{}, nil
+		}
+		return blkidSignature{}, fmt.Errorf("blkid failed on %s: %v, output: %s", devicePath, err, string(output))
+	}
+
+	var signature blkidSignature
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return blkidSignature{}, fmt.Errorf("blkid returned invalid output for %s: %s", devicePath, string(output))
+		}
+		switch key {
+		case "TYPE":
+			signature.FsType = value
+		case "PTTYPE":
+			signature.PtType = value
+		}
+	}
+	return signature, nil
+}
+
+// VerifyExistingFsType returns an error when the device already holds a
+// filesystem other than expectedFsType, or a partition table, so that it is
+// never mounted with the wrong type. Unformatted devices pass the check.
+func VerifyExistingFsType(logger *zap.SugaredLogger, devicePath, expectedFsType string) error {
+	return verifyExistingFsType(logger, NewCommandRunner(), devicePath, expectedFsType)
+}
+
+func verifyExistingFsType(logger *zap.SugaredLogger, runner CommandRunner, devicePath, expectedFsType string) error {
+	if expectedFsType == "" {
+		return fmt.Errorf("expected fsType must be provided to verify %s", devicePath)
+	}
+	signature, err := probeBlkidSignature(runner, devicePath)
+	if err != nil {
+		return err
+	}
+	if signature.unformatted() {
+		logger.With("devicePath", devicePath, "fsType", expectedFsType).Info("Device is not formatted yet.")
+		return nil
+	}
+	if signature.FsType == "" {
+		return fmt.Errorf("device %s holds a %s partition table instead of a %s filesystem", devicePath, signature.PtType, expectedFsType)
+	}
+	if !strings.EqualFold(signature.FsType, expectedFsType) {
+		return fmt.Errorf("device %s is formatted as %s but %s was requested", devicePath, signature.FsType, expectedFsType)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	utilexec "k8s.io/utils/exec"
+)
+
+const blkidCmd = "blkid -p -s TYPE -s PTTYPE -o export /dev/sdb"
+
+func Test_needsFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		runner  CommandRunner
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "Formatted device",
+			runner: &fakeCommandRunner{outputs: map[string][]byte{
+				blkidCmd: []byte("DEVNAME=/dev/sdb\nTYPE=ext4\n"),
+			}},
+			want: false,
+		},
+		{
+			name: "Partitioned device",
+			runner: &fakeCommandRunner{outputs: map[string][]byte{
+				blkidCmd: []byte("DEVNAME=/dev/sdb\nPTTYPE=gpt\n"),
+			}},
+			want: false,
+		},
+		{
+			name: "Unformatted device",
+			runner: &fakeCommandRunner{
+				outputs: map[string][]byte{blkidCmd: nil},
+				errs:    map[string]error{blkidCmd: utilexec.CodeExitError{Err: fmt.Errorf("exit status 2"), Code: 2}},
+			},
+			want: true,
+		},
+		{
+			name: "Failure to probe device",
+			runner: &fakeCommandRunner{
+				outputs: map[string][]byte{blkidCmd: []byte("error: /dev/sdb: No such file or directory")},
+				errs:    map[string]error{blkidCmd: utilexec.CodeExitError{Err: fmt.Errorf("exit status 4"), Code: 4}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid blkid output",
+			runner: &fakeCommandRunner{outputs: map[string][]byte{
+				blkidCmd: []byte("garbage\n"),
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := needsFormat(zap.S(), tt.runner, "/dev/sdb")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("needsFormat() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("needsFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_verifyExistingFsType(t *testing.T) {
+	ext4Runner := &fakeCommandRunner{outputs: map[string][]byte{
+		blkidCmd: []byte("DEVNAME=/dev/sdb\nTYPE=ext4\n"),
+	}}
+	tests := []struct {
+		name           string
+		runner         CommandRunner
+		expectedFsType string
+		wantErr        bool
+	}{
+		{
+			name:           "Matching filesystem",
+			runner:         ext4Runner,
+			expectedFsType: "ext4",
+		},
+		{
+			name:           "Mismatching filesystem",
+			runner:         ext4Runner,
+			expectedFsType: "xfs",
+			wantErr:        true,
+		},
+		{
+			name: "Unformatted device",
+			runner: &fakeCommandRunner{
+				outputs: map[string][]byte{blkidCmd: nil},
+				errs:    map[string]error{blkidCmd: utilexec.CodeExitError{Err: fmt.Errorf("exit status 2"), Code: 2}},
+			},
+			expectedFsType: "xfs",
+		},
+		{
+			name: "Partitioned device",
+			runner: &fakeCommandRunner{outputs: map[string][]byte{
+				blkidCmd: []byte("DEVNAME=/dev/sdb\nPTTYPE=gpt\n"),
+			}},
+			expectedFsType: "ext4",
+			wantErr:        true,
+		},
+		{
+			name:           "Missing expected fsType",
+			runner:         ext4Runner,
+			expectedFsType: "",
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyExistingFsType(zap.S(), tt.runner, "/dev/sdb", tt.expectedFsType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyExistingFsType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"fmt"
+	"strings"
+
+	utilexec "k8s.io/utils/exec"
+)
+
+// CommandRunner runs commands on the host, so that helpers which parse
+// command output can be tested with canned output.
+type CommandRunner interface {
+	// Run runs the named command and returns its combined stdout and stderr.
+	// Non zero exit codes are returned as a utilexec.ExitError.
+	Run(name string, args ...string) ([]byte, error)
+	// LookPath returns the full path of the named binary on PATH.
+	LookPath(name string) (string, error)
+}
+
+// execCommandRunner implements CommandRunner using k8s.io/utils/exec.
+type execCommandRunner struct {
+	exec utilexec.Interface
+}
+
+// NewCommandRunner returns a CommandRunner which executes commands on the host.
+func NewCommandRunner() CommandRunner {
+	return &execCommandRunner{exec: utilexec.New()}
+}
+
+func (r *execCommandRunner) Run(name string, args ...string) ([]byte, error) {
+	return r.exec.Command(name, args...).CombinedOutput()
+}
+
+func (r *execCommandRunner) LookPath(name string) (string, error) {
+	return r.exec.LookPath(name)
+}
+
+// CheckRequiredBinaries verifies that each of the named binaries is on PATH so
+// that a node missing one of them fails at startup rather than at volume time.
+// All missing binaries are reported together in the returned error, for the
+// caller to log.
+func CheckRequiredBinaries(runner CommandRunner, names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if _, err := runner.LookPath(name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("required binaries not found on PATH: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_CheckRequiredBinaries(t *testing.T) {
+	binDir := t.TempDir()
+	for _, name := range []string{"blockdev", "iscsiadm", "mkfs.ext4"} {
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "mkfs.xfs"), []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatalf("failed to create mkfs.xfs: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	tests := []struct {
+		name        string
+		names       []string
+		wantErr     bool
+		wantMissing []string
+	}{
+		{
+			name:  "All binaries present",
+			names: []string{"blockdev", "iscsiadm", "mkfs.ext4"},
+		},
+		{
+			name:        "Missing binary",
+			names:       []string{"blockdev", "rpm"},
+			wantErr:     true,
+			wantMissing: []string{"rpm"},
+		},
+		{
+			name:        "Missing binaries are reported together",
+			names:       []string{"rpm", "blockdev", "dnf"},
+			wantErr:     true,
+			wantMissing: []string{"rpm", "dnf"},
+		},
+		{
+			name:    "Binary present but not executable",
+			names:   []string{"mkfs.xfs"},
+			wantErr: true,
+		},
+		{
+			name: "No binaries required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRequiredBinaries(NewCommandRunner(), tt.names...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckRequiredBinaries() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			for _, name := range tt.wantMissing {
+				if !strings.Contains(err.Error(), name) {
+					t.Errorf("CheckRequiredBinaries() error = %v, want it to report %s", err, name)
+				}
+			}
+		})
+	}
+}
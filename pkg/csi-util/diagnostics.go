@@ -0,0 +1,107 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"k8s.io/mount-utils"
+
+	"github.com/oracle/oci-cloud-controller-manager/pkg/util/disk"
+)
+
+// VolumeDiagnostics gathers the node side state of a volume in a single
+// struct so that it can be logged when debugging a stuck volume.
+type VolumeDiagnostics struct {
+	Disk           string
+	Target         string
+	DevicePath     string
+	DeviceExists   bool
+	BlockSizeBytes int64
+	StagingPath    string
+	IsMounted      bool
+}
+
+// volumeDiagnosticsProbe holds the functions used to inspect the node, so
+// that they can be replaced in tests.
+type volumeDiagnosticsProbe struct {
+	devicePath   func(d *disk.Disk) (string, error)
+	pathExists   func(path string) bool
+	blockSize    func(devicePath string) (int64, error)
+	mountChecker mount.Interface
+}
+
+// CollectVolumeDiagnostics assembles device existence, block size and mount
+// state of the given iSCSI disk and staging path. Failures to probe individual
+// fields are returned as a combined error alongside the partially populated
+// diagnostics.
+func CollectVolumeDiagnostics(logger *zap.SugaredLogger, d *disk.Disk, stagingPath string) (VolumeDiagnostics, error) {
+	u := &Util{Logger: logger}
+	return collectVolumeDiagnostics(logger, d, stagingPath, volumeDiagnosticsProbe{
+		devicePath: disk.GetIscsiDevicePath,
+		pathExists: func(path string) bool {
+			return u.WaitForPathToExist(path, 1)
+		},
+		blockSize: func(devicePath string) (int64, error) {
+			return GetBlockSizeBytes(logger, devicePath)
+		},
+		mountChecker: mount.New(""),
+	})
+}
+
+func collectVolumeDiagnostics(logger *zap.SugaredLogger, d *disk.Disk, stagingPath string, probe volumeDiagnosticsProbe) (VolumeDiagnostics, error) {
+	diagnostics := VolumeDiagnostics{
+		StagingPath:    stagingPath,
+		BlockSizeBytes: -1,
+	}
+	if d == nil {
+		return diagnostics, fmt.Errorf("disk must be provided to collect volume diagnostics")
+	}
+	diagnostics.Disk = d.String()
+	diagnostics.Target = d.Target()
+
+	var errs []error
+
+	devicePath, err := probe.devicePath(d)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to find device path: %v", err))
+	} else {
+		diagnostics.DevicePath = devicePath
+		diagnostics.DeviceExists = probe.pathExists(devicePath)
+	}
+
+	if diagnostics.DeviceExists {
+		size, err := probe.blockSize(devicePath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get block size: %v", err))
+		} else {
+			diagnostics.BlockSizeBytes = size
+		}
+	}
+
+	if stagingPath != "" {
+		notMnt, err := probe.mountChecker.IsLikelyNotMountPoint(stagingPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to check mount state of %s: %v", stagingPath, err))
+		} else {
+			diagnostics.IsMounted = !notMnt
+		}
+	}
+
+	logger.With("diagnostics", diagnostics).Info("Collected volume diagnostics.")
+	return diagnostics, errors.Join(errs...)
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+	"k8s.io/mount-utils"
+
+	"github.com/oracle/oci-cloud-controller-manager/pkg/util/disk"
+)
+
+func Test_collectVolumeDiagnostics(t *testing.T) {
+	stagingPath := t.TempDir()
+	devicePath := "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca-lun-1"
+	testDisk := &disk.Disk{
+		IQN:     "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+		IscsiIp: "169.254.2.2",
+		Port:    3260,
+	}
+
+	tests := []struct {
+		name    string
+		disk    *disk.Disk
+		probe   volumeDiagnosticsProbe
+		want    VolumeDiagnostics
+		wantErr bool
+	}{
+		{
+			name: "Attached and mounted volume",
+			disk: testDisk,
+			probe: volumeDiagnosticsProbe{
+				devicePath: func(d *disk.Disk) (string, error) { return devicePath, nil },
+				pathExists: func(path string) bool { return true },
+				blockSize:  func(devicePath string) (int64, error) { return 50 * 1024 * 1024 * 1024, nil },
+				mountChecker: mount.NewFakeMounter([]mount.MountPoint{
+					{Device: "/dev/sdb", Path: stagingPath},
+				}),
+			},
+			want: VolumeDiagnostics{
+				Disk:           testDisk.String(),
+				Target:         "169.254.2.2:3260",
+				DevicePath:     devicePath,
+				DeviceExists:   true,
+				BlockSizeBytes: 50 * 1024 * 1024 * 1024,
+				StagingPath:    stagingPath,
+				IsMounted:      true,
+			},
+		},
+		{
+			name: "Device not logged in and staging path not mounted",
+			disk: testDisk,
+			probe: volumeDiagnosticsProbe{
+				devicePath:   func(d *disk.Disk) (string, error) { return "", fmt.Errorf("cannot find device path") },
+				pathExists:   func(path string) bool { return false },
+				blockSize:    func(devicePath string) (int64, error) { return -1, fmt.Errorf("unexpected call") },
+				mountChecker: mount.NewFakeMounter(nil),
+			},
+			want: VolumeDiagnostics{
+				Disk:           testDisk.String(),
+				Target:         "169.254.2.2:3260",
+				BlockSizeBytes: -1,
+				StagingPath:    stagingPath,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Nil disk",
+			disk:    nil,
+			probe:   volumeDiagnosticsProbe{},
+			want:    VolumeDiagnostics{StagingPath: stagingPath, BlockSizeBytes: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectVolumeDiagnostics(zap.S(), tt.disk, stagingPath, tt.probe)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("collectVolumeDiagnostics() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collectVolumeDiagnostics() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
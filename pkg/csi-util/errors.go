@@ -0,0 +1,57 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	k8sapierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/oracle/oci-cloud-controller-manager/pkg/oci/client"
+)
+
+// ErrNodeNotFound is returned when the node an operation targets no longer
+// exists, e.g. because it was deleted mid-operation. Retrying can not succeed.
+var ErrNodeNotFound = errors.New("node not found")
+
+// IsRetryable reports whether the operation which returned err should be
+// retried. Validation errors (InvalidArgument, OutOfRange) are never retried,
+// while kube api server timeouts and throttling, context deadlines and
+// retryable OCI service errors are.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+			return false
+		case codes.DeadlineExceeded, codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+			return true
+		}
+	}
+	if k8sapierrors.IsTimeout(err) || k8sapierrors.IsServerTimeout(err) ||
+		k8sapierrors.IsTooManyRequests(err) || k8sapierrors.IsServiceUnavailable(err) ||
+		k8sapierrors.IsInternalError(err) {
+		return true
+	}
+	return client.IsRetryable(err)
+}
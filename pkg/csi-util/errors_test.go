@@ -0,0 +1,159 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	k8sapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_IsRetryable(t *testing.T) {
+	_, lookupErr := (&Util{Logger: zap.S()}).LookupNodeID(fake.NewSimpleClientset(), "missing-node")
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "Nil error",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "Invalid performance level",
+			err:  status.Errorf(codes.InvalidArgument, "invalid performance option : 40 provided"),
+			want: false,
+		},
+		{
+			name: "Requested size out of range",
+			err:  status.Errorf(codes.OutOfRange, "invalid capacity range"),
+			want: false,
+		},
+		{
+			name: "Context deadline exceeded",
+			err:  fmt.Errorf("failed to load node metadata: %w", context.DeadlineExceeded),
+			want: true,
+		},
+		{
+			name: "Api server timeout",
+			err:  k8sapierrors.NewTimeoutError("request timed out", 1),
+			want: true,
+		},
+		{
+			name: "Api server too many requests",
+			err:  k8sapierrors.NewTooManyRequests("slow down", 1),
+			want: true,
+		},
+		{
+			name: "Wrapped api server timeout from node lookup",
+			err:  fmt.Errorf("fail to get the node node1: %w", k8sapierrors.NewServerTimeout(schema.GroupResource{Resource: "nodes"}, "get", 1)),
+			want: true,
+		},
+		{
+			name: "Node not found",
+			err:  lookupErr,
+			want: false,
+		},
+		{
+			name: "Unavailable grpc status",
+			err:  status.Error(codes.Unavailable, "connection refused"),
+			want: true,
+		},
+		{
+			name: "Plain error",
+			err:  fmt.Errorf("invalid iSCSIIp identified 169.254.2"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_LookupNodeIDNotFound(t *testing.T) {
+	u := &Util{Logger: zap.S()}
+	clientset := fake.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Spec:       v1.NodeSpec{ProviderID: "ocid1.instance.oc1.phx.aaaa"},
+	})
+	serverTimeoutClientset := fake.NewSimpleClientset()
+	serverTimeoutClientset.PrependReactor("get", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8sapierrors.NewServerTimeout(schema.GroupResource{Resource: "nodes"}, "get", 1)
+	})
+
+	tests := []struct {
+		name         string
+		clientset    *fake.Clientset
+		nodeName     string
+		want         string
+		wantErr      bool
+		wantNotFound bool
+	}{
+		{
+			name:      "Existing node",
+			clientset: clientset,
+			nodeName:  "node1",
+			want:      "ocid1.instance.oc1.phx.aaaa",
+		},
+		{
+			name:         "Deleted node",
+			clientset:    clientset,
+			nodeName:     "node2",
+			wantErr:      true,
+			wantNotFound: true,
+		},
+		{
+			name:      "Api server timeout",
+			clientset: serverTimeoutClientset,
+			nodeName:  "node1",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.LookupNodeID(tt.clientset, tt.nodeName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LookupNodeID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("LookupNodeID() = %v, want %v", got, tt.want)
+			}
+			if gotNotFound := errors.Is(err, ErrNodeNotFound); gotNotFound != tt.wantNotFound {
+				t.Errorf("errors.Is(err, ErrNodeNotFound) = %v, want %v", gotNotFound, tt.wantNotFound)
+			}
+			if tt.wantNotFound && !k8sapierrors.IsNotFound(err) {
+				t.Errorf("LookupNodeID() error = %v, want the api server NotFound error to be preserved", err)
+			}
+		})
+	}
+}
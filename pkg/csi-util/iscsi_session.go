@@ -0,0 +1,74 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	iscsiTargetPattern = regexp.MustCompile(`^Target:\s+(\S+)`)
+	iscsiPortalPattern = regexp.MustCompile(`^Current Portal:\s+(\S+?)(?:,\d+)?$`)
+	iscsiSIDPattern    = regexp.MustCompile(`^SID:\s+(\d+)`)
+	iscsiStatePattern  = regexp.MustCompile(`^iSCSI Session State:\s+(\S+)`)
+	iscsiDevicePattern = regexp.MustCompile(`^Attached scsi disk\s+(\S+)`)
+)
+
+// ISCSISessionDetail is a single session parsed from `iscsiadm -m session -P 3`.
+type ISCSISessionDetail struct {
+	TargetIQN string
+	// Portal is the current portal of the session as ip:port, with IPv6
+	// addresses in brackets.
+	Portal string
+	SID    string
+	State  string
+	// Devices are the names of the attached SCSI disks, e.g. sdb.
+	Devices []string
+}
+
+// ParseISCSISessionDetail parses the output of `iscsiadm -m session -P 3` into
+// one ISCSISessionDetail per session, mapping each session to its devices.
+func ParseISCSISessionDetail(output []byte) ([]ISCSISessionDetail, error) {
+	var sessions []ISCSISessionDetail
+	var target string
+	for _, rawLine := range strings.Split(string(output), "\n") {
+		line := strings.TrimSpace(rawLine)
+		if match := iscsiTargetPattern.FindStringSubmatch(line); match != nil {
+			target = match[1]
+			continue
+		}
+		if match := iscsiPortalPattern.FindStringSubmatch(line); match != nil {
+			if target == "" {
+				return nil, fmt.Errorf("found portal %s before any target in iscsiadm session output", match[1])
+			}
+			sessions = append(sessions, ISCSISessionDetail{TargetIQN: target, Portal: match[1]})
+			continue
+		}
+		if len(sessions) == 0 {
+			continue
+		}
+		current := &sessions[len(sessions)-1]
+		if match := iscsiSIDPattern.FindStringSubmatch(line); match != nil {
+			current.SID = match[1]
+		} else if match := iscsiStatePattern.FindStringSubmatch(line); match != nil {
+			current.State = match[1]
+		} else if match := iscsiDevicePattern.FindStringSubmatch(line); match != nil {
+			current.Devices = append(current.Devices, match[1])
+		}
+	}
+	return sessions, nil
+}
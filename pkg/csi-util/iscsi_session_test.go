@@ -0,0 +1,120 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sampleISCSISessionDetailOutput = `iSCSI Transport Class version 2.0-870
+version 6.2.1.4-1
+Target: iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca (non-flash)
+	Current Portal: 169.254.2.2:3260,1
+	Persistent Portal: 169.254.2.2:3260,1
+		**********
+		Interface:
+		**********
+		Iface Name: default
+		Iface Transport: tcp
+		Iface Initiatorname: iqn.1988-12.com.oracle:6dc1f1d2e3f4
+		SID: 1
+		iSCSI Connection State: LOGGED IN
+		iSCSI Session State: LOGGED_IN
+		Internal iscsid Session State: NO CHANGE
+		************************
+		Attached SCSI devices:
+		************************
+		Host Number: 3	State: running
+		scsi3 Channel 00 Id 0 Lun: 2
+			Attached scsi disk sdb		State: running
+Target: iqn.2015-12.com.oracleiaas:8d1b2c3a-1111-4a75-82d0-ee31a39471cb (non-flash)
+	Current Portal: [fd00:c1::a9fe:203]:3260,1
+	Persistent Portal: [fd00:c1::a9fe:203]:3260,1
+		SID: 2
+		iSCSI Connection State: LOGGED IN
+		iSCSI Session State: LOGGED_IN
+		************************
+		Attached SCSI devices:
+		************************
+		Host Number: 4	State: running
+		scsi4 Channel 00 Id 0 Lun: 3
+			Attached scsi disk sdc		State: running
+		scsi4 Channel 00 Id 0 Lun: 4
+			Attached scsi disk sdd		State: running
+	Current Portal: [fd00:c1::a9fe:204]:3260,1
+	Persistent Portal: [fd00:c1::a9fe:204]:3260,1
+		SID: 3
+		iSCSI Connection State: TRANSPORT WAIT
+		iSCSI Session State: FAILED
+`
+
+func Test_ParseISCSISessionDetail(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []ISCSISessionDetail
+		wantErr bool
+	}{
+		{
+			name:   "Multiple targets and sessions",
+			output: sampleISCSISessionDetailOutput,
+			want: []ISCSISessionDetail{
+				{
+					TargetIQN: "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+					Portal:    "169.254.2.2:3260",
+					SID:       "1",
+					State:     "LOGGED_IN",
+					Devices:   []string{"sdb"},
+				},
+				{
+					TargetIQN: "iqn.2015-12.com.oracleiaas:8d1b2c3a-1111-4a75-82d0-ee31a39471cb",
+					Portal:    "[fd00:c1::a9fe:203]:3260",
+					SID:       "2",
+					State:     "LOGGED_IN",
+					Devices:   []string{"sdc", "sdd"},
+				},
+				{
+					TargetIQN: "iqn.2015-12.com.oracleiaas:8d1b2c3a-1111-4a75-82d0-ee31a39471cb",
+					Portal:    "[fd00:c1::a9fe:204]:3260",
+					SID:       "3",
+					State:     "FAILED",
+				},
+			},
+		},
+		{
+			name:   "No active sessions",
+			output: "iscsiadm: No active sessions.\n",
+			want:   nil,
+		},
+		{
+			name:    "Portal without target",
+			output:  "\tCurrent Portal: 169.254.2.2:3260,1\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseISCSISessionDetail([]byte(tt.output))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseISCSISessionDetail() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseISCSISessionDetail() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/mount-utils"
+)
+
+// procMountInfoPath is the mountinfo of the mount namespace the driver runs in.
+const procMountInfoPath = "/proc/self/mountinfo"
+
+// FindMountsForDevice returns every mountpoint backed by the given device, so
+// that a device mounted at more than one target can be detected. Symlinks
+// such as /dev/disk/by-path links are resolved before matching.
+func FindMountsForDevice(devicePath string) ([]string, error) {
+	return findMountsForDevice(procMountInfoPath, devicePath)
+}
+
+func findMountsForDevice(mountInfoPath, devicePath string) ([]string, error) {
+	if devicePath == "" {
+		return nil, fmt.Errorf("device path must be provided to find its mounts")
+	}
+	sources := map[string]bool{devicePath: true}
+	if resolved, err := filepath.EvalSymlinks(devicePath); err == nil {
+		sources[resolved] = true
+	}
+
+	mountInfos, err := mount.ParseMountInfo(mountInfoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", mountInfoPath, err)
+	}
+
+	var mountPoints []string
+	for _, mi := range mountInfos {
+		if sources[mi.Source] {
+			mountPoints = append(mountPoints, mi.MountPoint)
+		}
+	}
+	return mountPoints, nil
+}
+
+// IsEncryptedMountActive reports whether the FSS volume mounted at mountPath is
+// mounted with in-transit encryption. Encrypted mounts are NFS mounts of an
+// export served by the local stunnel proxy of oci-fss-utils, so their source
+// server is a loopback address rather than the mount target IP. A path that is
+// not a mount point is reported as not encrypted.
+func IsEncryptedMountActive(mountPath string) (bool, error) {
+	return isEncryptedMountActive(procMountInfoPath, mountPath)
+}
+
+func isEncryptedMountActive(mountInfoPath, mountPath string) (bool, error) {
+	if mountPath == "" {
+		return false, fmt.Errorf("mount path must be provided to check for in-transit encryption")
+	}
+	mountInfos, err := mount.ParseMountInfo(mountInfoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s: %v", mountInfoPath, err)
+	}
+
+	mountPath = filepath.Clean(mountPath)
+	for _, mi := range mountInfos {
+		if mi.MountPoint != mountPath || !strings.HasPrefix(mi.FsType, "nfs") {
+			continue
+		}
+		server, _, err := ExtractFssExportFromMount(mi.Source)
+		if err != nil {
+			return false, err
+		}
+		if isLoopbackServer(server) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isLoopbackServer(server string) bool {
+	if server == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(server)
+	return ip != nil && ip.IsLoopback()
+}
+
+// defaultMountOptions are options which only restate the kernel defaults, so
+// their presence or absence does not change how a filesystem is mounted.
+var defaultMountOptions = sets.NewString("defaults", "rw", "relatime", "async", "suid", "dev", "exec", "auto", "nouser")
+
+// MountOptionsChanged reports whether a volume mounted with the current options
+// has to be remounted to apply the desired options. Options are compared
+// ignoring order, duplicates and options which restate the defaults, so e.g.
+// switching between ro and rw is a change while reordering options is not.
+func MountOptionsChanged(current, desired []string) bool {
+	return !effectiveMountOptions(current).Equal(effectiveMountOptions(desired))
+}
+
+func effectiveMountOptions(options []string) sets.String {
+	effective := sets.NewString()
+	for _, option := range options {
+		for _, o := range strings.Split(option, ",") {
+			o = strings.TrimSpace(o)
+			if o != "" && !defaultMountOptions.Has(o) {
+				effective.Insert(o)
+			}
+		}
+	}
+	return effective
+}
@@ -0,0 +1,230 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const sampleMountInfo = `22 1 8:1 / / rw,relatime shared:1 - xfs /dev/sda1 rw,attr2,inode64
+120 22 8:16 / /var/lib/kubelet/plugins/kubernetes.io/csi/blockvolume.csi.oraclecloud.com/abc/globalmount rw,relatime shared:60 - ext4 /dev/sdb rw
+125 22 8:16 / /var/lib/kubelet/pods/pod-1/volumes/kubernetes.io~csi/pvc-1/mount rw,relatime shared:60 - ext4 /dev/sdb rw
+130 22 8:32 / /var/lib/kubelet/plugins/kubernetes.io/csi/blockvolume.csi.oraclecloud.com/def/globalmount rw,relatime shared:61 - ext4 /dev/sdc rw
+`
+
+func Test_findMountsForDevice(t *testing.T) {
+	mountInfoPath := filepath.Join(t.TempDir(), "mountinfo")
+	if err := os.WriteFile(mountInfoPath, []byte(sampleMountInfo), 0644); err != nil {
+		t.Fatalf("failed to write mountinfo: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		mountInfoPath string
+		devicePath    string
+		want          []string
+		wantErr       bool
+	}{
+		{
+			name:          "Device mounted at multiple targets",
+			mountInfoPath: mountInfoPath,
+			devicePath:    "/dev/sdb",
+			want: []string{
+				"/var/lib/kubelet/plugins/kubernetes.io/csi/blockvolume.csi.oraclecloud.com/abc/globalmount",
+				"/var/lib/kubelet/pods/pod-1/volumes/kubernetes.io~csi/pvc-1/mount",
+			},
+		},
+		{
+			name:          "Device mounted once",
+			mountInfoPath: mountInfoPath,
+			devicePath:    "/dev/sdc",
+			want:          []string{"/var/lib/kubelet/plugins/kubernetes.io/csi/blockvolume.csi.oraclecloud.com/def/globalmount"},
+		},
+		{
+			name:          "Device not mounted",
+			mountInfoPath: mountInfoPath,
+			devicePath:    "/dev/sdd",
+			want:          nil,
+		},
+		{
+			name:          "Missing mountinfo",
+			mountInfoPath: filepath.Join(t.TempDir(), "missing"),
+			devicePath:    "/dev/sdb",
+			wantErr:       true,
+		},
+		{
+			name:          "Empty device path",
+			mountInfoPath: mountInfoPath,
+			devicePath:    "",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findMountsForDevice(tt.mountInfoPath, tt.devicePath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("findMountsForDevice() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findMountsForDevice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+const sampleFssMountInfo = `22 1 8:1 / / rw,relatime shared:1 - xfs /dev/sda1 rw,attr2,inode64
+140 22 0:52 / /var/lib/kubelet/plugins/kubernetes.io/csi/fss.csi.oraclecloud.com/abc/globalmount rw,relatime shared:70 - nfs 10.0.10.5:/export-a rw,vers=3
+145 22 0:53 / /var/lib/kubelet/plugins/kubernetes.io/csi/fss.csi.oraclecloud.com/def/globalmount rw,relatime shared:71 - nfs 127.0.0.1:/export-b rw,vers=3,port=20049
+150 22 0:54 / /var/lib/kubelet/plugins/kubernetes.io/csi/fss.csi.oraclecloud.com/ghi/globalmount rw,relatime shared:72 - nfs4 [::1]:/export-c rw,vers=4.1
+155 22 0:55 / /var/lib/kubelet/plugins/kubernetes.io/csi/fss.csi.oraclecloud.com/jkl/globalmount rw,relatime shared:73 - nfs [fd00:c1::a9fe:202]:/export-d rw,vers=3
+`
+
+func Test_isEncryptedMountActive(t *testing.T) {
+	mountInfoPath := filepath.Join(t.TempDir(), "mountinfo")
+	if err := os.WriteFile(mountInfoPath, []byte(sampleFssMountInfo), 0644); err != nil {
+		t.Fatalf("failed to write mountinfo: %v", err)
+	}
+	const stagingRoot = "/var/lib/kubelet/plugins/kubernetes.io/csi/fss.csi.oraclecloud.com/"
+
+	tests := []struct {
+		name          string
+		mountInfoPath string
+		mountPath     string
+		want          bool
+		wantErr       bool
+	}{
+		{
+			name:          "Plain NFS mount",
+			mountInfoPath: mountInfoPath,
+			mountPath:     stagingRoot + "abc/globalmount",
+			want:          false,
+		},
+		{
+			name:          "Encrypted mount through IPv4 loopback",
+			mountInfoPath: mountInfoPath,
+			mountPath:     stagingRoot + "def/globalmount/",
+			want:          true,
+		},
+		{
+			name:          "Encrypted mount through IPv6 loopback",
+			mountInfoPath: mountInfoPath,
+			mountPath:     stagingRoot + "ghi/globalmount",
+			want:          true,
+		},
+		{
+			name:          "Plain NFS mount of IPv6 mount target",
+			mountInfoPath: mountInfoPath,
+			mountPath:     stagingRoot + "jkl/globalmount",
+			want:          false,
+		},
+		{
+			name:          "Path not mounted",
+			mountInfoPath: mountInfoPath,
+			mountPath:     stagingRoot + "mno/globalmount",
+			want:          false,
+		},
+		{
+			name:          "Missing mountinfo",
+			mountInfoPath: filepath.Join(t.TempDir(), "missing"),
+			mountPath:     stagingRoot + "def/globalmount",
+			wantErr:       true,
+		},
+		{
+			name:          "Empty mount path",
+			mountInfoPath: mountInfoPath,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isEncryptedMountActive(tt.mountInfoPath, tt.mountPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("isEncryptedMountActive() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("isEncryptedMountActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_MountOptionsChanged(t *testing.T) {
+	tests := []struct {
+		name    string
+		current []string
+		desired []string
+		want    bool
+	}{
+		{
+			name:    "Identical options",
+			current: []string{"rw", "noatime", "nosuid"},
+			desired: []string{"rw", "noatime", "nosuid"},
+			want:    false,
+		},
+		{
+			name:    "Reordered options",
+			current: []string{"noatime", "nosuid"},
+			desired: []string{"nosuid", "noatime"},
+			want:    false,
+		},
+		{
+			name:    "Comma separated and duplicated options",
+			current: []string{"noatime,nosuid"},
+			desired: []string{"nosuid", "noatime", "nosuid"},
+			want:    false,
+		},
+		{
+			name:    "Defaults are ignored",
+			current: []string{"rw", "relatime", "noatime"},
+			desired: []string{"defaults", "noatime"},
+			want:    false,
+		},
+		{
+			name:    "Read write to read only",
+			current: []string{"rw", "relatime"},
+			desired: []string{"ro"},
+			want:    true,
+		},
+		{
+			name:    "Read only to read write",
+			current: []string{"ro"},
+			desired: []string{"rw"},
+			want:    true,
+		},
+		{
+			name:    "Option added",
+			current: []string{"noatime"},
+			desired: []string{"noatime", "nodev"},
+			want:    true,
+		},
+		{
+			name:    "Option value changed",
+			current: []string{"nfsvers=3"},
+			desired: []string{"nfsvers=4.1"},
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MountOptionsChanged(tt.current, tt.desired); got != tt.want {
+				t.Errorf("MountOptionsChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/oracle/oci-cloud-controller-manager/pkg/util/disk"
+)
+
+var (
+	// multipathMapPattern matches the first line of a map in `multipath -l`
+	// output, with or without user friendly names, e.g.
+	// mpatha (360f5b4e7d1c14c7e8b2a1a5d1c3f9e21) dm-0 ORACLE,BlockVolume
+	// 360f5b4e7d1c14c7e8b2a1a5d1c3f9e21 dm-0 ORACLE,BlockVolume
+	multipathMapPattern = regexp.MustCompile(`^(?:(\S+) \((\S+)\)|(\S+)) (dm-\d+)(?:\s|$)`)
+
+	// multipathPathPattern matches a path line of a map, e.g.
+	//   |- 3:0:0:2 sdb 8:16 active undef running
+	multipathPathPattern = regexp.MustCompile(`\d+:\d+:\d+:\d+\s+(\S+)\s+\d+:\d+`)
+)
+
+// scsiIdPath is the udev helper used to read the SCSI WWID of a device.
+const scsiIdPath = "/lib/udev/scsi_id"
+
+// multipathMap is a single multipath map parsed from `multipath -l`.
+type multipathMap struct {
+	Name    string
+	WWID    string
+	DmName  string
+	Devices []string
+}
+
+// FindMultipathDevice returns the /dev/mapper device of the multipath map
+// whose WWID matches the SCSI WWID of the given disk.
+func FindMultipathDevice(logger *zap.SugaredLogger, d *disk.Disk) (string, error) {
+	return findMultipathDevice(logger, NewCommandRunner(), d, diskPathDevices)
+}
+
+func findMultipathDevice(logger *zap.SugaredLogger, runner CommandRunner, d *disk.Disk, pathDevices func(d *disk.Disk) ([]string, error)) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("disk must be provided to find its multipath device")
+	}
+	devices, err := pathDevices(d)
+	if err != nil {
+		return "", fmt.Errorf("failed to find path devices of %s: %v", d, err)
+	}
+	wwid, err := scsiWWID(runner, devices)
+	if err != nil {
+		return "", fmt.Errorf("failed to get WWID of %s: %v", d, err)
+	}
+
+	output, err := runner.Run("multipath", "-l")
+	if err != nil {
+		return "", fmt.Errorf("multipath -l failed: %v, output: %s", err, string(output))
+	}
+
+	for _, m := range parseMultipathMaps(output) {
+		if strings.EqualFold(m.WWID, wwid) {
+			devicePath := "/dev/mapper/" + m.Name
+			logger.With("disk", d.String(), "wwid", m.WWID, "dm", m.DmName, "devicePath", devicePath).Info("Found multipath device for volume.")
+			return devicePath, nil
+		}
+	}
+	return "", fmt.Errorf("no multipath device found for %s with WWID %s", d, wwid)
+}
+
+// scsiWWID returns the SCSI WWID reported by scsi_id for the first of the
+// given path devices which reports one. All paths of a volume share its WWID.
+func scsiWWID(runner CommandRunner, devices []string) (string, error) {
+	var lastErr error
+	for _, device := range devices {
+		output, err := runner.Run(scsiIdPath, "-g", "-u", "-d", "/dev/"+device)
+		if err != nil {
+			lastErr = fmt.Errorf("%s failed for %s: %v, output: %s", scsiIdPath, device, err, string(output))
+			continue
+		}
+		wwid := strings.ToLower(strings.TrimSpace(string(output)))
+		if !wwidPattern.MatchString(wwid) {
+			lastErr = fmt.Errorf("invalid WWID %q reported for %s", strings.TrimSpace(string(output)), device)
+			continue
+		}
+		return wwid, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no path devices given")
+	}
+	return "", lastErr
+}
+
+// parseMultipathMaps parses the maps and their path devices from `multipath -l`.
+func parseMultipathMaps(output []byte) []multipathMap {
+	var maps []multipathMap
+	for _, line := range strings.Split(string(output), "\n") {
+		if match := multipathMapPattern.FindStringSubmatch(line); match != nil {
+			m := multipathMap{Name: match[1], WWID: match[2], DmName: match[4]}
+			if m.Name == "" {
+				// Without user friendly names the map is named by its WWID.
+				m.Name, m.WWID = match[3], match[3]
+			}
+			maps = append(maps, m)
+			continue
+		}
+		if len(maps) == 0 {
+			continue
+		}
+		if match := multipathPathPattern.FindStringSubmatch(line); match != nil {
+			maps[len(maps)-1].Devices = append(maps[len(maps)-1].Devices, match[1])
+		}
+	}
+	return maps
+}
+
+// diskPathDevices resolves the /dev/disk/by-path links of the disk to the
+// names of the underlying SCSI devices, e.g. sdb.
+func diskPathDevices(d *disk.Disk) ([]string, error) {
+	return diskPathDevicesIn(disk.DISK_BY_PATH_FOLDER, d)
+}
+
+func diskPathDevicesIn(folder string, d *disk.Disk) ([]string, error) {
+	links, err := listByPathLinksForTarget(folder, d)
+	if err != nil {
+		return nil, err
+	}
+	var devices []string
+	for _, link := range links {
+		target, err := filepath.EvalSymlinks(link)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, filepath.Base(target))
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("no %s links found", folder)
+	}
+	return devices, nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/oracle/oci-cloud-controller-manager/pkg/util/disk"
+)
+
+// fakeCommandRunner returns canned output for commands keyed by the full
+// command line.
+type fakeCommandRunner struct {
+	outputs map[string][]byte
+	errs    map[string]error
+	calls   []string
+}
+
+func (f *fakeCommandRunner) Run(name string, args ...string) ([]byte, error) {
+	cmd := strings.Join(append([]string{name}, args...), " ")
+	f.calls = append(f.calls, cmd)
+	if err, ok := f.errs[cmd]; ok {
+		return f.outputs[cmd], err
+	}
+	if output, ok := f.outputs[cmd]; ok {
+		return output, nil
+	}
+	return nil, fmt.Errorf("unexpected command %q", cmd)
+}
+
+func (f *fakeCommandRunner) LookPath(name string) (string, error) {
+	return "", fmt.Errorf("unexpected lookup of %q", name)
+}
+
+const sampleMultipathOutput = `mpatha (360f5b4e7d1c14c7e8b2a1a5d1c3f9e21) dm-0 ORACLE,BlockVolume
+size=50G features='4 queue_if_no_path retain_attached_hw_handler queue_mode bio' hwhandler='0' wp=rw
+` + "`" + `-+- policy='queue-length 0' prio=0 status=active
+  |- 3:0:0:2 sdb 8:16 active undef running
+  ` + "`" + `- 4:0:0:2 sdc 8:32 active undef running
+3605a2b4c1e9d4f8a9c6b7d8e9f0a1b23 dm-1 ORACLE,BlockVolume
+size=100G features='4 queue_if_no_path retain_attached_hw_handler queue_mode bio' hwhandler='0' wp=rw
+` + "`" + `-+- policy='queue-length 0' prio=0 status=active
+  |- 5:0:0:3 sdd 8:48 active undef running
+  ` + "`" + `- 6:0:0:3 sde 8:64 active undef running
+`
+
+func Test_findMultipathDevice(t *testing.T) {
+	testDisk := &disk.Disk{
+		IQN:     "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+		IscsiIp: "169.254.2.2",
+		Port:    3260,
+	}
+	multipathRunner := &fakeCommandRunner{outputs: map[string][]byte{
+		"multipath -l":                        []byte(sampleMultipathOutput),
+		"/lib/udev/scsi_id -g -u -d /dev/sdb": []byte("360f5b4e7d1c14c7e8b2a1a5d1c3f9e21\n"),
+		"/lib/udev/scsi_id -g -u -d /dev/sdc": []byte("360f5b4e7d1c14c7e8b2a1a5d1c3f9e21\n"),
+		"/lib/udev/scsi_id -g -u -d /dev/sdd": []byte("3605A2B4C1E9D4F8A9C6B7D8E9F0A1B23\n"),
+		"/lib/udev/scsi_id -g -u -d /dev/sdf": []byte("360f5b4e7d1c14c7e8b2a1a5d1c3f0000\n"),
+		"/lib/udev/scsi_id -g -u -d /dev/sdg": []byte("not a wwid\n"),
+	}}
+
+	tests := []struct {
+		name        string
+		disk        *disk.Disk
+		runner      CommandRunner
+		pathDevices []string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "Map with user friendly name",
+			disk:        testDisk,
+			runner:      multipathRunner,
+			pathDevices: []string{"sdc"},
+			want:        "/dev/mapper/mpatha",
+		},
+		{
+			name:        "Map named by WWID",
+			disk:        testDisk,
+			runner:      multipathRunner,
+			pathDevices: []string{"sdd"},
+			want:        "/dev/mapper/3605a2b4c1e9d4f8a9c6b7d8e9f0a1b23",
+		},
+		{
+			name:        "First path without a WWID is skipped",
+			disk:        testDisk,
+			runner:      multipathRunner,
+			pathDevices: []string{"sdg", "sdb"},
+			want:        "/dev/mapper/mpatha",
+		},
+		{
+			name:        "WWID not part of any map",
+			disk:        testDisk,
+			runner:      multipathRunner,
+			pathDevices: []string{"sdf"},
+			wantErr:     true,
+		},
+		{
+			name:        "scsi_id reports an invalid WWID",
+			disk:        testDisk,
+			runner:      multipathRunner,
+			pathDevices: []string{"sdg"},
+			wantErr:     true,
+		},
+		{
+			name:        "scsi_id fails",
+			disk:        testDisk,
+			runner:      multipathRunner,
+			pathDevices: []string{"sdh"},
+			wantErr:     true,
+		},
+		{
+			name: "multipath command fails",
+			disk: testDisk,
+			runner: &fakeCommandRunner{
+				outputs: map[string][]byte{"/lib/udev/scsi_id -g -u -d /dev/sdb": []byte("360f5b4e7d1c14c7e8b2a1a5d1c3f9e21\n")},
+				errs:    map[string]error{"multipath -l": fmt.Errorf("exit status 1")},
+			},
+			pathDevices: []string{"sdb"},
+			wantErr:     true,
+		},
+		{
+			name:    "Nil disk",
+			disk:    nil,
+			runner:  multipathRunner,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pathDevices := func(d *disk.Disk) ([]string, error) { return tt.pathDevices, nil }
+			got, err := findMultipathDevice(zap.S(), tt.runner, tt.disk, pathDevices)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("findMultipathDevice() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("findMultipathDevice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_diskPathDevicesIn(t *testing.T) {
+	iqn := "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca"
+	dir := t.TempDir() + "/"
+	for _, device := range []string{"sdb", "sdc"} {
+		if err := os.WriteFile(dir+device, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-2":         "sdb",
+		"ip-[fd00:c1::a9fe:202]:3260-iscsi-" + iqn + "-lun-2": "sdc",
+	}
+	for link, device := range links {
+		if err := os.Symlink(dir+device, dir+link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		disk    *disk.Disk
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "IPv4 target",
+			disk: &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			want: []string{"sdb"},
+		},
+		{
+			name: "IPv6 target",
+			disk: &disk.Disk{IQN: iqn, IscsiIp: "fd00:c1::a9fe:202", Port: 3260},
+			want: []string{"sdc"},
+		},
+		{
+			name:    "No links for target",
+			disk:    &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.3", Port: 3260},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diskPathDevicesIn(dir, tt.disk)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("diskPathDevicesIn() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diskPathDevicesIn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
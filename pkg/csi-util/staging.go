@@ -0,0 +1,105 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/mount-utils"
+)
+
+const (
+	// stagingDirGlob matches the per volume staging directories kubelet
+	// creates under its root, i.e.
+	// plugins/kubernetes.io/csi/<driver or pv>/<volume hash or pv name>/globalmount
+	stagingDirGlob = "plugins/kubernetes.io/csi/*/*/globalmount"
+)
+
+// FindOrphanedStagingDirs lists the staging directories under kubeletRoot
+// which exist but have nothing mounted on them, e.g. left behind when the node
+// driver crashed during unstage, so that they can be cleaned up. A raw block
+// staging directory is in use while its staging file is mounted.
+func FindOrphanedStagingDirs(kubeletRoot string) ([]string, error) {
+	return findOrphanedStagingDirs(kubeletRoot, mount.New(""))
+}
+
+func findOrphanedStagingDirs(kubeletRoot string, mounter mount.Interface) ([]string, error) {
+	stagingDirs, err := filepath.Glob(filepath.Join(kubeletRoot, stagingDirGlob))
+	if err != nil {
+		return nil, err
+	}
+
+	var orphaned []string
+	for _, stagingDir := range stagingDirs {
+		if info, err := os.Stat(stagingDir); err != nil || !info.IsDir() {
+			continue
+		}
+		mounted, err := isStagingPathMounted(mounter, stagingDir)
+		if err != nil {
+			return nil, err
+		}
+		if mounted {
+			continue
+		}
+		blockStagingFile := GetPathForBlock(stagingDir)
+		if _, err := os.Stat(blockStagingFile); err == nil {
+			mounted, err = isStagingPathMounted(mounter, blockStagingFile)
+			if err != nil {
+				return nil, err
+			}
+			if mounted {
+				continue
+			}
+		}
+		orphaned = append(orphaned, stagingDir)
+	}
+	return orphaned, nil
+}
+
+func isStagingPathMounted(mounter mount.Interface, stagingPath string) (bool, error) {
+	notMnt, err := mounter.IsLikelyNotMountPoint(stagingPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to check mount state of %s: %v", stagingPath, err)
+	}
+	return !notMnt, nil
+}
+
+// ValidateDistinctPaths returns an error when the staging and target paths
+// refer to the same directory or one is nested in the other, as publishing
+// would then bind mount the volume onto itself.
+func ValidateDistinctPaths(staging, target string) error {
+	cleanStaging := filepath.Clean(staging)
+	cleanTarget := filepath.Clean(target)
+	if cleanStaging == cleanTarget {
+		return fmt.Errorf("staging path %s and target path %s must differ", staging, target)
+	}
+	if isSubPath(cleanStaging, cleanTarget) || isSubPath(cleanTarget, cleanStaging) {
+		return fmt.Errorf("staging path %s and target path %s must not be nested in each other", staging, target)
+	}
+	return nil
+}
+
+// isSubPath reports whether child is located below parent. Both paths must be
+// cleaned.
+func isSubPath(parent, child string) bool {
+	rel, err := filepath.Rel(parent, child)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
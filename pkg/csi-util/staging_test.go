@@ -0,0 +1,136 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/mount-utils"
+)
+
+func Test_findOrphanedStagingDirs(t *testing.T) {
+	kubeletRoot := t.TempDir()
+	csiRoot := filepath.Join(kubeletRoot, "plugins", "kubernetes.io", "csi")
+	mountedDir := filepath.Join(csiRoot, "blockvolume.csi.oraclecloud.com", "0f1e2d", "globalmount")
+	orphanedDir := filepath.Join(csiRoot, "fss.csi.oraclecloud.com", "3c4b5a", "globalmount")
+	legacyOrphanedDir := filepath.Join(csiRoot, "pv", "pvc-1", "globalmount")
+	rawBlockDir := filepath.Join(csiRoot, "blockvolume.csi.oraclecloud.com", "6d7e8f", "globalmount")
+	for _, dir := range []string{mountedDir, orphanedDir, legacyOrphanedDir, rawBlockDir} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(GetPathForBlock(rawBlockDir), nil, 0640); err != nil {
+		t.Fatalf("failed to create raw block staging file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		kubeletRoot string
+		mountPoints []mount.MountPoint
+		want        []string
+	}{
+		{
+			name:        "Mix of mounted and orphaned staging dirs",
+			kubeletRoot: kubeletRoot,
+			mountPoints: []mount.MountPoint{
+				{Device: "/dev/sdb", Path: mountedDir},
+				{Device: "/dev/sdc", Path: GetPathForBlock(rawBlockDir)},
+			},
+			want: []string{orphanedDir, legacyOrphanedDir},
+		},
+		{
+			name:        "Unmounted raw block staging dir is orphaned",
+			kubeletRoot: kubeletRoot,
+			mountPoints: []mount.MountPoint{
+				{Device: "/dev/sdb", Path: mountedDir},
+			},
+			want: []string{rawBlockDir, orphanedDir, legacyOrphanedDir},
+		},
+		{
+			name:        "No staging dirs",
+			kubeletRoot: t.TempDir(),
+			want:        nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findOrphanedStagingDirs(tt.kubeletRoot, mount.NewFakeMounter(tt.mountPoints))
+			if err != nil {
+				t.Errorf("findOrphanedStagingDirs() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findOrphanedStagingDirs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidateDistinctPaths(t *testing.T) {
+	const staging = "/var/lib/kubelet/plugins/kubernetes.io/csi/blockvolume.csi.oraclecloud.com/abc/globalmount"
+	tests := []struct {
+		name    string
+		staging string
+		target  string
+		wantErr bool
+	}{
+		{
+			name:    "Distinct paths",
+			staging: staging,
+			target:  "/var/lib/kubelet/pods/pod-1/volumes/kubernetes.io~csi/pvc-1/mount",
+		},
+		{
+			name:    "Sibling with common name prefix",
+			staging: staging,
+			target:  staging + "-1",
+		},
+		{
+			name:    "Equal paths",
+			staging: staging,
+			target:  staging,
+			wantErr: true,
+		},
+		{
+			name:    "Equal paths after cleaning",
+			staging: staging,
+			target:  staging + "/./",
+			wantErr: true,
+		},
+		{
+			name:    "Target nested in staging",
+			staging: staging,
+			target:  staging + "/mount",
+			wantErr: true,
+		},
+		{
+			name:    "Staging nested in target",
+			staging: staging,
+			target:  "/var/lib/kubelet/plugins",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDistinctPaths(tt.staging, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDistinctPaths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -16,12 +16,20 @@ package csi_util
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math/big"
+	"math/rand"
 	"net"
+	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -36,8 +44,11 @@ import (
 	"google.golang.org/grpc/status"
 	"gopkg.in/yaml.v3"
 	kubeAPI "k8s.io/api/core/v1"
+	k8sapierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -55,12 +66,37 @@ const (
 	// to create a volume that is larger than what we support
 	MaximumVolumeSizeInBytes int64 = 32 * client.TiB
 
+	// AbsoluteMaximumVolumeSizeInBytes is the ceiling for any override of the
+	// maximum volume size made through SetMaximumVolumeSize
+	AbsoluteMaximumVolumeSizeInBytes int64 = 1024 * client.TiB
+
 	// defaultVolumeSizeInBytes is used when the user did not provide a size or
 	// the size they provided did not satisfy our requirements
 	defaultVolumeSizeInBytes int64 = MinimumVolumeSizeInBytes
 
 	waitForPathDelay = 1 * time.Second
 
+	// defaultWaitForPathRetries is the number of path checks used when no
+	// deadline is available to derive it from.
+	defaultWaitForPathRetries = 20
+
+	driverSocketPollInterval = 100 * time.Millisecond
+
+	// csiSocketName is the file name of the socket every driver listens on
+	csiSocketName = "csi.sock"
+
+	// maxDriverNameLength is the maximum length of a driver name allowed by the CSI spec
+	maxDriverNameLength = 63
+
+	// volumeHandleHashBytes is the number of sha256 bytes kept by VolumeHandleHash
+	volumeHandleHashBytes = 8
+
+	// loopControlPath is the control device of the kernel loop driver
+	loopControlPath = "/dev/loop-control"
+
+	// extResizeBlockSizeBytes is the ext3/ext4 block size used for resize2fs size arguments
+	extResizeBlockSizeBytes int64 = 4 * client.KiB
+
 	// ociVolumeBackupID is the name of the oci volume backup id annotation.
 	ociVolumeBackupID = "volume.beta.kubernetes.io/oci-volume-source"
 
@@ -70,6 +106,9 @@ const (
 	BalancedPerformanceOption     = 10
 	HigherPerformanceOption       = 20
 	MaxUltraHighPerformanceOption = 120
+	// MinRawBlockRestrictedPerformanceOption is the lowest performance level
+	// which can not be provisioned with the Block volumeMode.
+	MinRawBlockRestrictedPerformanceOption = 30
 
 	InTransitEncryptionPackageName = "oci-fss-utils"
 	FIPS_ENABLED_FILE_PATH         = "/host/proc/sys/crypto/fips_enabled"
@@ -88,6 +127,33 @@ const (
 
 	AvailabilityDomainLabel = "csi-ipv6-full-ad-name"
 
+	// FSS VolumeContext keys describing the export the node plugin mounts
+	FssFilesystemOcidKey = "filesystemOcid"
+	FssMountTargetIpKey  = "mountTargetIp"
+	FssExportPathKey     = "exportPath"
+
+	// FssMountTargetAvailabilityDomainKey is the VolumeContext key holding the
+	// availability domain of the mount target.
+	FssMountTargetAvailabilityDomainKey = "mountTargetAvailabilityDomain"
+
+	// NFS timeo (in deciseconds) and retrans values for FSS mounts. Mount
+	// targets in another AD see higher latency and are given more headroom.
+	sameADNFSTimeo    = 600
+	sameADNFSRetrans  = 2
+	crossADNFSTimeo   = 900
+	crossADNFSRetrans = 3
+)
+
+var (
+	// maximumVolumeSizeInBytes is the maximum volume size consulted by
+	// ExtractStorage. Defaults to MaximumVolumeSizeInBytes.
+	maximumVolumeSizeInBytes    = MaximumVolumeSizeInBytes
+	maximumVolumeSizeInBytesMux sync.RWMutex
+
+	// iscsiIpv6Prefix is the prefix consulted by ConvertIscsiIpFromIpv4ToIpv6.
+	// Defaults to IscsiIpv6Prefix.
+	iscsiIpv6Prefix    = IscsiIpv6Prefix
+	iscsiIpv6PrefixMux sync.RWMutex
 )
 
 // Util interface
@@ -98,6 +164,19 @@ type Util struct {
 var (
 	DiskByPathPatternPV    = `/dev/disk/by-path/pci-\w{4}:\w{2}:\w{2}\.\d+-scsi-\d+:\d+:\d+:\d+$`
 	DiskByPathPatternISCSI = `/dev/disk/by-path/ip-[[?\w\.\:]+]?:\d+-iscsi-[\w\.\-:]+-lun-\d+$`
+
+	// iqnPattern matches iSCSI qualified names of the form
+	// iqn.yyyy-mm.naming-authority:unique e.g. iqn.2015-12.com.oracleiaas:<uuid>
+	iqnPattern = regexp.MustCompile(`^iqn\.\d{4}-(0[1-9]|1[0-2])\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*:[a-zA-Z0-9.:-]+$`)
+
+	// wwidPattern matches SCSI WWIDs as reported by scsi_id and multipath
+	wwidPattern = regexp.MustCompile(`^[0-9a-f]+$`)
+
+	// InitiatorNameFile is the open-iscsi file holding the node's initiator name
+	InitiatorNameFile = "/etc/iscsi/initiatorname.iscsi"
+
+	// ProcFilesystemsFile lists the filesystem types supported by the kernel
+	ProcFilesystemsFile = "/proc/filesystems"
 )
 
 type FSSVolumeHandler struct {
@@ -112,7 +191,20 @@ type NodeMetadata struct {
 	Ipv6Enabled            bool
 	AvailabilityDomain     string
 	FullAvailabilityDomain string
+	Region                 string
 	IsNodeMetadataLoaded   bool
+	// IpFamilyDefaulted is set when the node carries no IP family labels and
+	// IPv4 was assumed rather than read from the node.
+	IpFamilyDefaulted bool
+}
+
+// IpFamilyString returns a concise summary of the node IP family for logging,
+// e.g. preferred=IPv6,v4=false,v6=true
+func (nm *NodeMetadata) IpFamilyString() string {
+	if nm == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("preferred=%s,v4=%t,v6=%t", nm.PreferredNodeIpFamily, nm.Ipv4Enabled, nm.Ipv6Enabled)
 }
 
 // CSIConfig represents the structure of the ConfigMap data.
@@ -132,7 +224,10 @@ func (u *Util) LookupNodeID(k kubernetes.Interface, nodeName string) (string, er
 	n, err := k.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
 	if err != nil {
 		u.Logger.With(zap.Error(err)).With("node", nodeName).Error("Failed to get Node by name.")
-		return "", fmt.Errorf("fail to get the node %s", nodeName)
+		if k8sapierrors.IsNotFound(err) {
+			return "", fmt.Errorf("fail to get the node %s: %w: %w", nodeName, ErrNodeNotFound, err)
+		}
+		return "", fmt.Errorf("fail to get the node %s: %w", nodeName, err)
 	}
 	if n.Spec.ProviderID == "" {
 		u.Logger.With("node", nodeName).Error("ProvideID is missing.")
@@ -171,6 +266,32 @@ func (u *Util) WaitForKubeApiServerToBeReachableWithContext(ctx context.Context,
 	)
 }
 
+// JitteredBackoff returns how long to wait before retry number attempt, counted
+// from 0. The wait is drawn uniformly from [0, base*2^attempt], with the upper
+// bound capped at max, so that concurrent retries spread out ("full jitter").
+func JitteredBackoff(attempt int, base, max time.Duration) time.Duration {
+	ceiling := backoffCeiling(attempt, base, max)
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// backoffCeiling returns base*2^attempt capped at max, without overflowing.
+func backoffCeiling(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 || max <= 0 {
+		return 0
+	}
+	ceiling := base
+	for i := 0; i < attempt && ceiling < max; i++ {
+		ceiling *= 2
+	}
+	if ceiling > max {
+		return max
+	}
+	return ceiling
+}
+
 func (u *Util) LoadNodeMetadataFromApiServer(ctx context.Context, k kubernetes.Interface, nodeID string, nodeMetadata *NodeMetadata) (error) {
 
 	u.WaitForKubeApiServerToBeReachableWithContext(ctx, k, time.Second * 30)
@@ -182,15 +303,29 @@ func (u *Util) LoadNodeMetadataFromApiServer(ctx context.Context, k kubernetes.I
 		return fmt.Errorf("Failed to get node information from kube api server, please check if kube api server is accessible.")
 	}
 
+	loadNodeMetadataFromLabels(node, nodeMetadata)
+	if !nodeMetadata.Ipv4Enabled && !nodeMetadata.Ipv6Enabled {
+		nodeMetadata.PreferredNodeIpFamily = Ipv4Stack
+		nodeMetadata.Ipv4Enabled = true
+		nodeMetadata.IpFamilyDefaulted = true
+		u.Logger.With("nodeId", nodeID, "ipFamily", nodeMetadata.IpFamilyString(), "nodeMetadata", nodeMetadata).Warn("No IP family labels identified on node, defaulting to ipv4.")
+	} else {
+		u.Logger.With("nodeId", nodeID, "ipFamily", nodeMetadata.IpFamilyString(), "nodeMetadata", nodeMetadata).Info("Node IP family identified.")
+	}
+	nodeMetadata.IsNodeMetadataLoaded = true
+	return  nil
+}
+
+// loadNodeMetadataFromLabels fills the availability domain, region and IP
+// family details of nodeMetadata from the labels of the node.
+func loadNodeMetadataFromLabels(node *kubeAPI.Node, nodeMetadata *NodeMetadata) {
 	var ok bool
 	if node.Labels != nil {
-		nodeMetadata.AvailabilityDomain, ok = node.Labels[kubeAPI.LabelTopologyZone]
-		if !ok {
-			nodeMetadata.AvailabilityDomain, ok = node.Labels[kubeAPI.LabelZoneFailureDomain]
-		}
+		nodeMetadata.AvailabilityDomain, ok = GetNodeAvailabilityDomainLabel(node)
 		if ok {
 			nodeMetadata.FullAvailabilityDomain, _ = node.Labels[AvailabilityDomainLabel]
 		}
+		nodeMetadata.Region, _ = GetNodeRegionLabel(node)
 
 		if preferredIpFamily, ok := node.Labels[LabelIpFamilyPreferred]; ok {
 			nodeMetadata.PreferredNodeIpFamily = FormatValidIpStackInK8SConvention(preferredIpFamily)
@@ -202,15 +337,56 @@ func (u *Util) LoadNodeMetadataFromApiServer(ctx context.Context, k kubernetes.I
 			nodeMetadata.Ipv6Enabled = true
 		}
 	}
-	if !nodeMetadata.Ipv4Enabled && !nodeMetadata.Ipv6Enabled {
-		nodeMetadata.PreferredNodeIpFamily = Ipv4Stack
-		nodeMetadata.Ipv4Enabled = true
-		u.Logger.With("nodeId", nodeID, "nodeMetadata", nodeMetadata).Info("No IP family labels identified on node, defaulting to ipv4.")
-	} else {
-		u.Logger.With("nodeId", nodeID, "nodeMetadata", nodeMetadata).Info("Node IP family identified.")
+}
+
+// GetNodeAvailabilityDomainLabel returns the availability domain of the node
+// from the topology zone label, falling back to the deprecated failure domain
+// label, and whether either label was found.
+func GetNodeAvailabilityDomainLabel(node *kubeAPI.Node) (string, bool) {
+	if node == nil {
+		return "", false
 	}
-	nodeMetadata.IsNodeMetadataLoaded = true
-	return  nil
+	if ad, ok := node.Labels[kubeAPI.LabelTopologyZone]; ok {
+		return ad, true
+	}
+	ad, ok := node.Labels[kubeAPI.LabelZoneFailureDomain]
+	return ad, ok
+}
+
+// GetNodeRegionLabel returns the region of the node from the topology region
+// label and whether a non empty label was found.
+func GetNodeRegionLabel(node *kubeAPI.Node) (string, bool) {
+	if node == nil {
+		return "", false
+	}
+	region, ok := node.Labels[kubeAPI.LabelTopologyRegion]
+	return region, ok && region != ""
+}
+
+// NodeTopologySegments returns the topology segments a node driver reports in
+// NodeGetInfo: the availability domain of the node, its region when the node
+// carries the topology region label, plus its full availability domain name
+// on IPv6 single stack nodes.
+func NodeTopologySegments(nodeMetadata *NodeMetadata) (map[string]string, error) {
+	if nodeMetadata == nil || nodeMetadata.AvailabilityDomain == "" {
+		return nil, fmt.Errorf("availability domain of node not found")
+	}
+	segments := map[string]string{
+		kubeAPI.LabelZoneFailureDomain: nodeMetadata.AvailabilityDomain,
+		kubeAPI.LabelTopologyZone:      nodeMetadata.AvailabilityDomain,
+	}
+	if nodeMetadata.Region != "" {
+		segments[kubeAPI.LabelTopologyRegion] = nodeMetadata.Region
+	}
+
+	//set full ad name in segments only for IPv6 single stack
+	if IsIpv6SingleStackNode(nodeMetadata) {
+		if nodeMetadata.FullAvailabilityDomain == "" {
+			return nil, fmt.Errorf("full availability domain name of IPv6 single stack node not found")
+		}
+		segments[AvailabilityDomainLabel] = nodeMetadata.FullAvailabilityDomain
+	}
+	return segments, nil
 }
 
 // waitForPathToExist waits for for a given filesystem path to exist.
@@ -232,6 +408,54 @@ func (u *Util) WaitForPathToExist(path string, maxRetries int) bool {
 	return false
 }
 
+// RetriesFromDeadline returns how many attempts spaced by interval fit before
+// the context deadline, so that WaitForPathToExist style waits end with the RPC.
+// At least one attempt is always returned. Contexts without a deadline get
+// defaultWaitForPathRetries.
+func RetriesFromDeadline(ctx context.Context, interval time.Duration) int {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return defaultWaitForPathRetries
+	}
+	remaining := time.Until(deadline)
+	if interval <= 0 || remaining <= 0 {
+		return 1
+	}
+	// The first attempt happens immediately, every following one after interval.
+	return int(remaining/interval) + 1
+}
+
+// WaitForUdevSettle waits for udev to finish processing events, so that the
+// /dev/disk/by-path links of a freshly attached device exist before we wait on
+// them. The wait is bounded by timeout and the context deadline. Nodes without
+// udevadm are tolerated.
+func WaitForUdevSettle(ctx context.Context, timeout time.Duration, logger *zap.SugaredLogger) error {
+	return waitForUdevSettle(ctx, NewCommandRunner(), timeout, logger)
+}
+
+func waitForUdevSettle(ctx context.Context, runner CommandRunner, timeout time.Duration, logger *zap.SugaredLogger) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	seconds := int64((timeout + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	output, err := runner.Run("udevadm", "settle", fmt.Sprintf("--timeout=%d", seconds))
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			logger.Warn("udevadm not found, not waiting for udev to settle.")
+			return nil
+		}
+		return fmt.Errorf("udevadm settle failed: %v, output: %s", err, string(output))
+	}
+	return nil
+}
+
 // convert "zkJl:US-ASHBURN-AD-1" to "US-ASHBURN-AD-1"
 func (u *Util) GetAvailableDomainInNodeLabel(fullAD string) string {
 	adElements := strings.Split(fullAD, ":")
@@ -250,6 +474,9 @@ func ExtractISCSIInformation(attributes map[string]string) (*disk.Disk, error) {
 	if !ok {
 		return nil, fmt.Errorf("unable to get the IQN from the attribute list")
 	}
+	if err := ValidateIQN(iqn); err != nil {
+		return nil, err
+	}
 	iSCSIIp, ok := attributes[disk.ISCSIIP]
 	if !ok {
 		return nil, fmt.Errorf("unable to get the iSCSIIp from the attribute list")
@@ -271,6 +498,104 @@ func ExtractISCSIInformation(attributes map[string]string) (*disk.Disk, error) {
 	}, nil
 }
 
+// ExtractFSSInformation returns the filesystem, mount target IP and export path
+// of an FSS volume from its VolumeContext. The mount target IP may be a
+// bracketed IPv6 address or a DNS name, as in the volume handle.
+func ExtractFSSInformation(attributes map[string]string) (*FSSVolumeHandler, error) {
+	filesystemOcid, ok := attributes[FssFilesystemOcidKey]
+	if !ok || filesystemOcid == "" {
+		return nil, fmt.Errorf("unable to get the filesystem OCID from the attribute list")
+	}
+	if err := ValidateOCIDOfType(filesystemOcid, "filesystem"); err != nil {
+		return nil, err
+	}
+	mountTargetIp, ok := attributes[FssMountTargetIpKey]
+	if !ok || mountTargetIp == "" {
+		return nil, fmt.Errorf("unable to get the mount target IP from the attribute list")
+	}
+	if net.ParseIP(strings.Trim(mountTargetIp, "[]")) == nil && !ValidateDNSName(mountTargetIp) {
+		return nil, fmt.Errorf("invalid mount target IP %s", mountTargetIp)
+	}
+	exportPath, ok := attributes[FssExportPathKey]
+	if !ok || exportPath == "" {
+		return nil, fmt.Errorf("unable to get the export path from the attribute list")
+	}
+	if err := ValidateExportPath(exportPath); err != nil {
+		return nil, err
+	}
+
+	return &FSSVolumeHandler{
+		FilesystemOcid:       filesystemOcid,
+		MountTargetIPAddress: mountTargetIp,
+		FsExportPath:         exportPath,
+	}, nil
+}
+
+// MountTargetAvailabilityDomain returns the availability domain of the mount
+// target recorded in the VolumeContext of an FSS volume, so that it can be
+// compared with the AD of the node. Volumes provisioned before the attribute
+// was added report false.
+func MountTargetAvailabilityDomain(attributes map[string]string) (string, bool) {
+	ad := strings.TrimSpace(attributes[FssMountTargetAvailabilityDomainKey])
+	if ad == "" {
+		return "", false
+	}
+	return ad, true
+}
+
+// ExtractWWID returns the SCSI WWID of the volume from the given attributes,
+// used to resolve its multipath device. An empty WWID is returned when the
+// attribute is absent.
+func ExtractWWID(attributes map[string]string) (string, error) {
+	wwid, ok := attributes[disk.SCSIWWID]
+	if !ok {
+		return "", nil
+	}
+	wwid = strings.ToLower(strings.TrimSpace(wwid))
+	if !wwidPattern.MatchString(wwid) {
+		return "", fmt.Errorf("invalid WWID %q, expected a hexadecimal identifier", attributes[disk.SCSIWWID])
+	}
+	return wwid, nil
+}
+
+// ValidateIQN checks that the given IQN is of the form
+// iqn.yyyy-mm.naming-authority:unique
+func ValidateIQN(iqn string) error {
+	if !iqnPattern.MatchString(iqn) {
+		return fmt.Errorf("invalid IQN %q, expected format iqn.yyyy-mm.naming-authority:unique", iqn)
+	}
+	return nil
+}
+
+// GetInitiatorName returns the iSCSI initiator name of the node as configured
+// in InitiatorNameFile.
+func GetInitiatorName() (string, error) {
+	return getInitiatorName(InitiatorNameFile)
+}
+
+func getInitiatorName(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read initiator name file %s: %v", path, err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found || strings.TrimSpace(key) != "InitiatorName" {
+			continue
+		}
+		initiatorName := strings.TrimSpace(value)
+		if err := ValidateIQN(initiatorName); err != nil {
+			return "", fmt.Errorf("invalid initiator name in %s: %v", path, err)
+		}
+		return initiatorName, nil
+	}
+	return "", fmt.Errorf("no InitiatorName found in %s", path)
+}
+
 // Extracts the vpusPerGB as int64 from given string input
 func ExtractBlockVolumePerformanceLevel(attribute string) (int64, error) {
 	vpusPerGB, err := strconv.ParseInt(attribute, 10, 64)
@@ -284,6 +609,160 @@ func ExtractBlockVolumePerformanceLevel(attribute string) (int64, error) {
 	return vpusPerGB, nil
 }
 
+// ExtractBlockVolumePerformanceLevelOrDefault behaves like
+// ExtractBlockVolumePerformanceLevel but returns BalancedPerformanceOption when
+// no performance level was provided.
+func ExtractBlockVolumePerformanceLevelOrDefault(attribute string) (int64, error) {
+	if attribute == "" {
+		return BalancedPerformanceOption, nil
+	}
+	return ExtractBlockVolumePerformanceLevel(attribute)
+}
+
+// DetectPerformanceMismatch reports whether the performance level declared in
+// the volume context differs from the actual vpusPerGB of the volume, e.g.
+// after an out-of-band update, so that the volume can be reconciled. A volume
+// context without a performance level declares the balanced default.
+func DetectPerformanceMismatch(volumeContext map[string]string, actualVpus int64) (bool, error) {
+	declaredVpus, err := ExtractBlockVolumePerformanceLevelOrDefault(volumeContext[VpusPerGB])
+	if err != nil {
+		return false, err
+	}
+	return declaredVpus != actualVpus, nil
+}
+
+// ValidatePerformanceForAccessType rejects performance levels which can not be
+// used with the requested access type. Ultra High Performance volumes of
+// MinRawBlockRestrictedPerformanceOption vpusPerGB and above are attached with
+// multipath and are only supported with the Filesystem volumeMode, so they can
+// not be requested as raw block volumes.
+func ValidatePerformanceForAccessType(vpusPerGB int64, isBlock bool) error {
+	if vpusPerGB < LowCostPerformanceOption || vpusPerGB > MaxUltraHighPerformanceOption {
+		return status.Errorf(codes.InvalidArgument, "invalid performance option : %d. Supported values for performance options are between %d and %d",
+			vpusPerGB, LowCostPerformanceOption, MaxUltraHighPerformanceOption)
+	}
+	if isBlock && vpusPerGB >= MinRawBlockRestrictedPerformanceOption {
+		return status.Errorf(codes.InvalidArgument, "failed to support Block volumeMode for Ultra High Performance Volumes (vpusPerGB >= %d)", MinRawBlockRestrictedPerformanceOption)
+	}
+	return nil
+}
+
+// CanChangePerformanceOnline returns whether the performance of an attached
+// volume can be changed from currentVpus to requestedVpus without a detach.
+// Ultra High Performance volumes (vpusPerGB > 20) need a multipath enabled
+// attachment, which is set up at attach time, so moving into or out of the
+// Ultra High Performance range requires the volume to be reattached. Values
+// outside the supported range are never allowed.
+func CanChangePerformanceOnline(currentVpus, requestedVpus int64) bool {
+	for _, vpus := range []int64{currentVpus, requestedVpus} {
+		if vpus < LowCostPerformanceOption || vpus > MaxUltraHighPerformanceOption {
+			return false
+		}
+	}
+	return (currentVpus > HigherPerformanceOption) == (requestedVpus > HigherPerformanceOption)
+}
+
+// ValidatePerformanceDowngrade returns an error when lowering the performance of
+// a volume from currentVpus to requestedVpus is not permitted. Upgrades and
+// unchanged levels are always permitted here; a downgrade out of the Ultra
+// High Performance range can not be applied while the volume is attached with
+// multipath, see CanChangePerformanceOnline.
+func ValidatePerformanceDowngrade(currentVpus, requestedVpus int64) error {
+	for _, vpus := range []int64{currentVpus, requestedVpus} {
+		if vpus < LowCostPerformanceOption || vpus > MaxUltraHighPerformanceOption {
+			return status.Errorf(codes.InvalidArgument, "invalid performance option : %d. Supported values for performance options are between %d and %d",
+				vpus, LowCostPerformanceOption, MaxUltraHighPerformanceOption)
+		}
+	}
+	if requestedVpus >= currentVpus {
+		return nil
+	}
+	if !CanChangePerformanceOnline(currentVpus, requestedVpus) {
+		return status.Errorf(codes.FailedPrecondition, "performance can not be lowered from %d to %d vpusPerGB while the volume is attached, "+
+			"Ultra High Performance volumes (vpusPerGB > %d) must be detached before leaving the Ultra High Performance range",
+			currentVpus, requestedVpus, HigherPerformanceOption)
+	}
+	return nil
+}
+
+// maxPerformanceKeyEditDistance is the largest edit distance from vpusPerGB at
+// which a storage class parameter key is reported as a likely typo.
+const maxPerformanceKeyEditDistance = 2
+
+// ExtractBlockVolumePerformanceLevelFromParams looks up the vpusPerGB key in the
+// given storage class parameters ignoring case, so that mis-cased keys such as
+// VpusPerGB are not silently ignored. Keys that look like typos of vpusPerGB,
+// such as vpus-per-gb or vpuPerGB, are logged as warnings and otherwise
+// ignored. Defaults to balanced performance when the key is absent.
+func ExtractBlockVolumePerformanceLevelFromParams(logger *zap.SugaredLogger, params map[string]string) (int64, error) {
+	var matchedKeys []string
+	for key := range params {
+		if strings.EqualFold(key, VpusPerGB) {
+			matchedKeys = append(matchedKeys, key)
+		}
+	}
+	for _, key := range nearMissPerformanceKeys(params) {
+		logger.With("key", key, "expectedKey", VpusPerGB).Warn("Storage class parameter key looks like a misspelling of the performance level key and is ignored.")
+	}
+	if len(matchedKeys) == 0 {
+		return BalancedPerformanceOption, nil
+	}
+	sort.Strings(matchedKeys)
+
+	value := params[matchedKeys[0]]
+	for _, key := range matchedKeys[1:] {
+		if params[key] != value {
+			return 0, status.Errorf(codes.InvalidArgument, "conflicting values provided for duplicate performance level keys %v", matchedKeys)
+		}
+	}
+	for _, key := range matchedKeys {
+		if key != VpusPerGB {
+			logger.With("key", key, "expectedKey", VpusPerGB).Warn("Storage class parameter key does not match the expected case, using its value.")
+		}
+	}
+	return ExtractBlockVolumePerformanceLevel(value)
+}
+
+// nearMissPerformanceKeys returns the sorted keys of params which are not
+// vpusPerGB in any case, but are within maxPerformanceKeyEditDistance of it
+// once lower cased and stripped of separators.
+func nearMissPerformanceKeys(params map[string]string) []string {
+	expected := strings.ToLower(VpusPerGB)
+	var keys []string
+	for key := range params {
+		if strings.EqualFold(key, VpusPerGB) {
+			continue
+		}
+		normalized := strings.NewReplacer("-", "", "_", "", ".", "", " ", "").Replace(strings.ToLower(key))
+		if editDistance(normalized, expected) <= maxPerformanceKeyEditDistance {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
 func ExtractISCSIInformationFromMountPath(logger *zap.SugaredLogger, diskPath []string) (*disk.Disk, error) {
 
 	logger.Info("Getting ISCSIInfo for the mount path: ", diskPath)
@@ -307,142 +786,721 @@ func ExtractISCSIInformationFromMountPath(logger *zap.SugaredLogger, diskPath []
 	}, nil
 }
 
-func GetKubeClient(logger *zap.SugaredLogger, master, kubeconfig string) *kubernetes.Clientset {
-	var (
-		config *rest.Config
-		err    error
-	)
-
-	if master != "" || kubeconfig != "" {
-		config, err = clientcmd.BuildConfigFromFlags(master, kubeconfig)
-	} else {
-		config, err = rest.InClusterConfig()
-		if err != nil {
-			logger.With(zap.Error(err)).Fatal("Failed to get the kubeconfig in cluster.")
-		}
+// ProbeISCSIPortal dials the iSCSI portal of the given disk over TCP so that an
+// unreachable target fails fast instead of waiting out the iSCSI login timeout.
+func ProbeISCSIPortal(ctx context.Context, d *disk.Disk, timeout time.Duration) error {
+	if d == nil {
+		return fmt.Errorf("disk must be provided to probe the iSCSI portal")
 	}
-
-	kubeClientSet, err := kubernetes.NewForConfig(config)
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", d.Target())
 	if err != nil {
-		logger.With(zap.Error(err)).Fatal("Failed to create a kubernetes clientset.")
-	} else {
-		logger.Info("Created kubernetes client successfully.")
+		return fmt.Errorf("iSCSI portal %s is not reachable: %v", d.Target(), err)
 	}
-	return kubeClientSet
+	return conn.Close()
 }
 
-// Get the staging target filepath inside the given stagingTargetPath, to be used for raw block volume support
-func GetPathForBlock(volumePath string) string {
-	pathForBlock := filepath.Join(volumePath, RawBlockStagingFile)
-	return pathForBlock
+// DevicePathForDisk returns the /dev/disk/by-path link expected to appear for
+// the given disk and lun after iSCSI login. IPv6 portals are bracketed.
+func DevicePathForDisk(d *disk.Disk, lun int) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("disk must be provided to build its device path")
+	}
+	iscsiIp := strings.Trim(d.IscsiIp, "[]")
+	if net.ParseIP(iscsiIp) == nil {
+		return "", fmt.Errorf("invalid iSCSIIp identified %s", d.IscsiIp)
+	}
+	if d.Port <= 0 || d.Port > 65535 {
+		return "", fmt.Errorf("invalid iSCSI port %d", d.Port)
+	}
+	if err := ValidateIQN(d.IQN); err != nil {
+		return "", err
+	}
+	if lun < 0 {
+		return "", fmt.Errorf("invalid lun %d", lun)
+	}
+	link := iscsiByPathLink{ip: net.ParseIP(iscsiIp), port: d.Port, iqn: d.IQN, lun: lun}
+	return disk.DISK_BY_PATH_FOLDER + link.String(), nil
 }
 
-// Creates a file on the specified path after creating the containing directory
-func CreateFilePath(logger *zap.SugaredLogger, path string) error {
-	pathDir := filepath.Dir(path)
-
-	err := os.MkdirAll(pathDir, 0750)
-	if err != nil {
-		logger.With(zap.Error(err)).Fatal("failed to create surrounding directory")
-		return err
-	}
+// iscsiByPathLinkPattern matches the name of an iSCSI /dev/disk/by-path link,
+// e.g. ip-169.254.2.2:3260-iscsi-<IQN>-lun-1. IPv6 portals may or may not be
+// bracketed.
+var iscsiByPathLinkPattern = regexp.MustCompile(`^ip-(\[?[\w.:]+\]?):(\d+)-iscsi-([\w.\-:]+)-lun-(\d+)$`)
+
+// iscsiByPathLink is the portal, target IQN and lun an iSCSI
+// /dev/disk/by-path link name is made of.
+type iscsiByPathLink struct {
+	ip   net.IP
+	port int
+	iqn  string
+	lun  int
+}
 
-	file, fileErr := os.OpenFile(path, os.O_CREATE, 0640)
-	if fileErr != nil && !os.IsExist(fileErr) {
-		logger.With(zap.Error(err)).Fatal("failed to create/open the target file")
-		return fileErr
+// parseISCSIByPathLink parses the name of an iSCSI /dev/disk/by-path link and
+// reports whether it is one.
+func parseISCSIByPathLink(name string) (iscsiByPathLink, bool) {
+	m := iscsiByPathLinkPattern.FindStringSubmatch(name)
+	if m == nil {
+		return iscsiByPathLink{}, false
 	}
-
-	fileErr = file.Close()
-	if fileErr != nil {
-		logger.With(zap.Error(err)).Fatal("failed to close the target file")
-		return fileErr
+	ip := net.ParseIP(strings.Trim(m[1], "[]"))
+	port, portErr := strconv.Atoi(m[2])
+	lun, lunErr := strconv.Atoi(m[4])
+	if ip == nil || portErr != nil || lunErr != nil {
+		return iscsiByPathLink{}, false
 	}
+	return iscsiByPathLink{ip: ip, port: port, iqn: m[3], lun: lun}, true
+}
 
-	return nil
+// String returns the name of the link, with IPv6 portals bracketed.
+func (l iscsiByPathLink) String() string {
+	target := (&disk.Disk{IQN: l.iqn, IscsiIp: l.ip.String(), Port: l.port}).Target()
+	return fmt.Sprintf("ip-%s-iscsi-%s-lun-%d", target, l.iqn, l.lun)
 }
 
-func MaxOfInt(a, b int64) int64 {
-	if a > b {
-		return a
+// matches returns true if the link belongs to the target IQN of the given
+// disk and, when the disk has them, to its iSCSI IP and port.
+func (l iscsiByPathLink) matches(d *disk.Disk, portalIp net.IP) bool {
+	if l.iqn != d.IQN {
+		return false
 	}
-	return b
+	if portalIp != nil && !portalIp.Equal(l.ip) {
+		return false
+	}
+	return d.Port <= 0 || d.Port == l.port
 }
 
-func FormatBytes(inputBytes int64) string {
-	output := float64(inputBytes)
-	unit := ""
+// ResolvePersistentDevicePath returns the /dev/disk/by-path link of the given
+// disk and lun, which unlike /dev/sdX names is stable across node reboots,
+// after validating that the link resolves to an existing device.
+func ResolvePersistentDevicePath(d *disk.Disk, lun int) (string, error) {
+	return resolvePersistentDevicePath("/", d, lun)
+}
 
-	switch {
-	case inputBytes >= client.TiB:
-		output = output / client.TiB
-		unit = "Ti"
-	case inputBytes >= client.GiB:
-		output = output / client.GiB
-		unit = "Gi"
-	case inputBytes >= client.MiB:
-		output = output / client.MiB
-		unit = "Mi"
-	case inputBytes >= client.KiB:
-		output = output / client.KiB
-		unit = "Ki"
-	case inputBytes == 0:
-		return "0"
-	}
-
-	result := strconv.FormatFloat(output, 'f', 1, 64)
-	result = strings.TrimSuffix(result, ".0")
-	return result + unit
-}
-
-func ValidateFsType(logger *zap.SugaredLogger, fsType string) string {
-	defaultFsType := "ext4"
-	if fsType == "ext4" || fsType == "ext3" || fsType == "xfs" {
-		return fsType
-	} else if fsType != "" {
-		//TODO: Remove this code when we support other than ext4 || ext3.
-		logger.With("fsType", fsType).Warn("Supporting only 'ext4/ext3/xfs' as fsType.")
-		return defaultFsType
-	} else {
-		//No fsType provided returning ext4
-		return defaultFsType
+func resolvePersistentDevicePath(rootDir string, d *disk.Disk, lun int) (string, error) {
+	devicePath, err := DevicePathForDisk(d, lun)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(rootDir, devicePath))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve persistent device path %s: %v", devicePath, err)
+	}
+	fi, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat device %s of %s: %v", resolved, devicePath, err)
 	}
+	if fi.Mode()&os.ModeDevice == 0 {
+		return "", fmt.Errorf("persistent device path %s resolves to %s which is not a device", devicePath, resolved)
+	}
+	return devicePath, nil
 }
 
-type VolumeLocks struct {
-	locks sets.String
-	mux   sync.Mutex
+// ListByPathLinksForTarget returns every /dev/disk/by-path link of the iSCSI
+// target IQN of the given disk, across all of its luns. When the disk has an
+// iSCSI IP and port only links of that portal are returned, otherwise links
+// of every portal, e.g. all paths of a multipath volume, are.
+func ListByPathLinksForTarget(d *disk.Disk) ([]string, error) {
+	return listByPathLinksForTarget(disk.DISK_BY_PATH_FOLDER, d)
 }
 
-func NewVolumeLocks() *VolumeLocks {
-	return &VolumeLocks{
-		locks: sets.NewString(),
+func listByPathLinksForTarget(byPathDir string, d *disk.Disk) ([]string, error) {
+	if d == nil || d.IQN == "" {
+		return nil, fmt.Errorf("disk with an IQN must be provided to list its by-path links")
+	}
+	var portalIp net.IP
+	if d.IscsiIp != "" {
+		if portalIp = net.ParseIP(strings.Trim(d.IscsiIp, "[]")); portalIp == nil {
+			return nil, fmt.Errorf("invalid iSCSIIp identified %s", d.IscsiIp)
+		}
+	}
+	entries, err := os.ReadDir(byPathDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %v", byPathDir, err)
 	}
-}
 
-func (vl *VolumeLocks) TryAcquire(volumeID string) bool {
-	vl.mux.Lock()
-	defer vl.mux.Unlock()
-	if vl.locks.Has(volumeID) {
-		return false
+	var links []string
+	for _, entry := range entries {
+		if link, ok := parseISCSIByPathLink(entry.Name()); ok && link.matches(d, portalIp) {
+			links = append(links, filepath.Join(byPathDir, entry.Name()))
+		}
 	}
-	vl.locks.Insert(volumeID)
-	return true
+	return links, nil
 }
 
-func (vl *VolumeLocks) Release(volumeID string) {
-	vl.mux.Lock()
-	defer vl.mux.Unlock()
-	vl.locks.Delete(volumeID)
+// PruneStaleByPathLinks removes the iSCSI and paravirtualized
+// /dev/disk/by-path links left pointing at devices which no longer exist after
+// a detach, so that they are not picked up by a later attach. The removed links
+// are returned.
+func PruneStaleByPathLinks(logger *zap.SugaredLogger) ([]string, error) {
+	return pruneStaleByPathLinks(logger, disk.DISK_BY_PATH_FOLDER)
 }
 
-// extractStorage extracts the storage size in bytes from the given capacity
-// range. If the capacity range is not satisfied it returns the default volume
-// size. If the capacity range is below or above supported sizes, it returns an
-// error.
-func ExtractStorage(capRange *csi.CapacityRange) (int64, error) {
-	if capRange == nil {
-		return defaultVolumeSizeInBytes, nil
+func pruneStaleByPathLinks(logger *zap.SugaredLogger, byPathDir string) ([]string, error) {
+	entries, err := os.ReadDir(byPathDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %v", byPathDir, err)
+	}
+
+	var pruned []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		matchedISCSI, _ := regexp.MatchString(DiskByPathPatternISCSI, disk.DISK_BY_PATH_FOLDER+name)
+		matchedPV, _ := regexp.MatchString(DiskByPathPatternPV, disk.DISK_BY_PATH_FOLDER+name)
+		if !matchedISCSI && !matchedPV {
+			continue
+		}
+		link := filepath.Join(byPathDir, name)
+		if _, err := os.Stat(link); !os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+			return pruned, fmt.Errorf("failed to remove stale link %s: %v", link, err)
+		}
+		logger.With("link", link).Info("Removed stale by-path link.")
+		pruned = append(pruned, link)
+	}
+	return pruned, nil
+}
+
+// ValidateDriverSocketConfig checks that the endpoint the driver listens on
+// and the socket path the node-driver-registrar registers with the kubelet
+// refer to the same socket. The registration path is expected to be of the form
+// <kubelet plugins dir>/<driver name>/<socket file>. An empty registration path
+// is not validated.
+func ValidateDriverSocketConfig(name, endpoint, registrationPath string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to parse endpoint %s for driver %s: %v", endpoint, name, err)
+	}
+	if u.Scheme != "unix" {
+		return fmt.Errorf("endpoint %s for driver %s must be a unix domain socket", endpoint, name)
+	}
+	endpointPath := path.Join(u.Host, u.Path)
+	if endpointPath == "" || strings.HasSuffix(endpointPath, "/") {
+		return fmt.Errorf("endpoint %s for driver %s does not specify a socket file", endpoint, name)
+	}
+
+	if registrationPath == "" {
+		return nil
+	}
+	if !path.IsAbs(registrationPath) {
+		return fmt.Errorf("registration path %s for driver %s must be absolute", registrationPath, name)
+	}
+	if path.Base(endpointPath) != path.Base(registrationPath) {
+		return fmt.Errorf("socket name mismatch for driver %s: endpoint %s uses %q but registration path %s uses %q",
+			name, endpoint, path.Base(endpointPath), registrationPath, path.Base(registrationPath))
+	}
+	if registrationDir := path.Base(path.Dir(registrationPath)); registrationDir != name {
+		return fmt.Errorf("registration path %s for driver %s must be inside a directory named after the driver, found %q",
+			registrationPath, name, registrationDir)
+	}
+	return nil
+}
+
+// ParseEndpoint parses a unix:// CSI endpoint and returns its scheme and the
+// socket address to listen on. The parent directory of the socket must exist
+// and the address itself must not be an existing directory, as otherwise the
+// bind fails with an error that does not point at the misconfigured flag.
+func ParseEndpoint(endpoint string) (string, string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse the address: %s", endpoint)
+	}
+
+	// CSI plugins talk only over UNIX sockets currently
+	if u.Scheme != "unix" {
+		return "", "", fmt.Errorf("currently only unix domain sockets are supported, have: %s", u.Scheme)
+	}
+
+	addr := path.Join(u.Host, filepath.FromSlash(u.Path))
+	if u.Host == "" {
+		addr = filepath.FromSlash(u.Path)
+	}
+	if addr == "" {
+		return "", "", fmt.Errorf("endpoint %s does not specify a socket path", endpoint)
+	}
+
+	if fi, err := os.Stat(addr); err == nil && fi.IsDir() {
+		return "", "", fmt.Errorf("endpoint %s points at the directory %s, expected a socket file path", endpoint, addr)
+	}
+	parent := filepath.Dir(addr)
+	if fi, err := os.Stat(parent); err != nil {
+		return "", "", fmt.Errorf("parent directory %s of endpoint %s is not accessible: %v", parent, endpoint, err)
+	} else if !fi.IsDir() {
+		return "", "", fmt.Errorf("parent %s of endpoint %s is not a directory", parent, endpoint)
+	}
+	return u.Scheme, addr, nil
+}
+
+// RemoveStaleSocket removes the unix domain socket of the given endpoint left
+// behind by a previous instance of the plugin. Only unix endpoints refer to a
+// filesystem path, so it is a no-op for any other scheme, e.g. tcp://host:port.
+func RemoveStaleSocket(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to parse the address: %s", endpoint)
+	}
+	if u.Scheme != "unix" {
+		return nil
+	}
+
+	_, addr, err := ParseEndpoint(endpoint)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(addr); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove unix domain socket file %s: %v", addr, err)
+	}
+	return nil
+}
+
+// ValidateDriverName checks the name follows the CSI spec naming rules: a
+// lowercase RFC 1123 DNS subdomain of at most 63 characters in reverse domain
+// form, e.g. blockvolume.csi.oraclecloud.com.
+func ValidateDriverName(name string) error {
+	if name == "" {
+		return fmt.Errorf("driver name must be provided")
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("driver name %q is not a valid DNS subdomain: %s", name, strings.Join(errs, "; "))
+	}
+	if len(name) > maxDriverNameLength {
+		return fmt.Errorf("driver name %q must be at most %d characters", name, maxDriverNameLength)
+	}
+	if !strings.Contains(name, ".") {
+		return fmt.Errorf("driver name %q must be in reverse domain form, e.g. blockvolume.csi.oraclecloud.com", name)
+	}
+	return nil
+}
+
+// RegistrationSocketPath returns the canonical path of the socket of the
+// given driver on the host, i.e. <kubeletRoot>/plugins/<driverName>/csi.sock,
+// as passed to the node-driver-registrar as the kubelet registration path.
+func RegistrationSocketPath(kubeletRoot, driverName string) string {
+	return JoinCSIPath(kubeletRoot, "plugins", driverName, csiSocketName)
+}
+
+// WaitForDriverSockets waits until the unix socket of every given endpoint
+// accepts connections, so that startup fails unless all enabled drivers are
+// serving within the timeout. Endpoints may be unix:// URLs or plain socket
+// paths.
+func WaitForDriverSockets(endpoints []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, endpoint := range endpoints {
+		socketPath := endpoint
+		if u, err := url.Parse(endpoint); err == nil && u.Scheme != "" {
+			if u.Scheme != "unix" {
+				return fmt.Errorf("endpoint %s must be a unix domain socket", endpoint)
+			}
+			socketPath = path.Join(u.Host, u.Path)
+		}
+		err := wait.PollUntilContextTimeout(ctx, driverSocketPollInterval, timeout, true, func(context.Context) (bool, error) {
+			conn, err := net.DialTimeout("unix", socketPath, driverSocketPollInterval)
+			if err != nil {
+				return false, nil
+			}
+			conn.Close()
+			return true, nil
+		})
+		if err != nil {
+			return fmt.Errorf("socket %s did not accept connections within %v: %v", socketPath, timeout, err)
+		}
+	}
+	return nil
+}
+
+func GetKubeClient(logger *zap.SugaredLogger, master, kubeconfig string) *kubernetes.Clientset {
+	var (
+		config *rest.Config
+		err    error
+	)
+
+	if master != "" || kubeconfig != "" {
+		config, err = clientcmd.BuildConfigFromFlags(master, kubeconfig)
+	} else {
+		config, err = rest.InClusterConfig()
+		if err != nil {
+			logger.With(zap.Error(err)).Fatal("Failed to get the kubeconfig in cluster.")
+		}
+	}
+
+	kubeClientSet, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		logger.With(zap.Error(err)).Fatal("Failed to create a kubernetes clientset.")
+	} else {
+		logger.Info("Created kubernetes client successfully.")
+	}
+	return kubeClientSet
+}
+
+// Get the staging target filepath inside the given stagingTargetPath, to be used for raw block volume support
+func GetPathForBlock(volumePath string) string {
+	pathForBlock := JoinCSIPath(volumePath, RawBlockStagingFile)
+	return pathForBlock
+}
+
+// StagingPathForVolume returns the staging directory of the given volume under
+// base. The subdirectory is named after the VolumeHandleHash of the volume ID,
+// so it is stable across calls, distinct per volume and free of the characters
+// volume IDs may contain.
+func StagingPathForVolume(base, volumeID string) string {
+	return JoinCSIPath(base, VolumeHandleHash(volumeID))
+}
+
+// RecommendedNFSTimeouts returns the timeo and retrans mount options to use for
+// an FSS mount, depending on whether the mount target is in the node's AD.
+func RecommendedNFSTimeouts(sameAD bool) (timeo int, retrans int) {
+	if sameAD {
+		return sameADNFSTimeo, sameADNFSRetrans
+	}
+	return crossADNFSTimeo, crossADNFSRetrans
+}
+
+// JoinCSIPath joins the given path elements using forward slashes and cleans
+// the result. CSI paths are always POSIX regardless of the OS we run on.
+func JoinCSIPath(parts ...string) string {
+	slashed := make([]string, 0, len(parts))
+	for _, part := range parts {
+		slashed = append(slashed, strings.ReplaceAll(part, "\\", "/"))
+	}
+	return path.Join(slashed...)
+}
+
+// Creates a file on the specified path after creating the containing directory
+func CreateFilePath(logger *zap.SugaredLogger, path string) error {
+	pathDir := filepath.Dir(path)
+
+	err := os.MkdirAll(pathDir, 0750)
+	if err != nil {
+		logger.With(zap.Error(err)).Fatal("failed to create surrounding directory")
+		return err
+	}
+
+	file, fileErr := os.OpenFile(path, os.O_CREATE, 0640)
+	if fileErr != nil && !os.IsExist(fileErr) {
+		logger.With(zap.Error(err)).Fatal("failed to create/open the target file")
+		return fileErr
+	}
+
+	fileErr = file.Close()
+	if fileErr != nil {
+		logger.With(zap.Error(err)).Fatal("failed to close the target file")
+		return fileErr
+	}
+
+	return nil
+}
+
+func MaxOfInt(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// byteUnit is a binary size suffix and the number of bytes it stands for.
+type byteUnit struct {
+	suffix string
+	bytes  int64
+}
+
+// byteUnits are ordered from largest to smallest so FormatBytes picks the
+// largest unit not exceeding the value.
+var byteUnits = []byteUnit{
+	{"Ei", client.EiB},
+	{"Pi", client.PiB},
+	{"Ti", client.TiB},
+	{"Gi", client.GiB},
+	{"Mi", client.MiB},
+	{"Ki", client.KiB},
+}
+
+// FormatBytes formats the given number of bytes for messages using the largest
+// binary unit not exceeding it, rounded to one decimal place, e.g. 50Gi or
+// 1.3Gi. The rounding makes the result display-only: ParseBytes accepts it but
+// only returns the original value when it is a whole or half unit.
+func FormatBytes(inputBytes int64) string {
+	for _, unit := range byteUnits {
+		if inputBytes >= unit.bytes {
+			result := strconv.FormatFloat(float64(inputBytes)/float64(unit.bytes), 'f', 1, 64)
+			return strings.TrimSuffix(result, ".0") + unit.suffix
+		}
+	}
+	return strconv.FormatInt(inputBytes, 10)
+}
+
+// byteQuantityPattern matches the output of FormatBytes, e.g. 50Gi or 1.5Ti.
+var byteQuantityPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(Ki|Mi|Gi|Ti|Pi|Ei)?$`)
+
+// ParseBytes parses a size in the format produced by FormatBytes, e.g. 50Gi,
+// 1.5Ti or 0, back into bytes. Fractional sizes are rounded to the nearest byte.
+func ParseBytes(input string) (int64, error) {
+	match := byteQuantityPattern.FindStringSubmatch(strings.TrimSpace(input))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %q", input)
+	}
+	value, ok := new(big.Rat).SetString(match[1])
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q", input)
+	}
+	for _, unit := range byteUnits {
+		if unit.suffix == match[2] {
+			value.Mul(value, new(big.Rat).SetInt64(unit.bytes))
+			break
+		}
+	}
+	// Round half up: floor((2*num + den) / (2*den)).
+	num := new(big.Int).Mul(value.Num(), big.NewInt(2))
+	num.Add(num, value.Denom())
+	bytes := num.Quo(num, new(big.Int).Mul(value.Denom(), big.NewInt(2)))
+	if !bytes.IsInt64() {
+		return 0, fmt.Errorf("size %q overflows int64", input)
+	}
+	return bytes.Int64(), nil
+}
+
+// ToQuantityString formats the given number of bytes as a canonical Kubernetes
+// quantity (e.g. 50Gi) as produced by resource.Quantity.
+func ToQuantityString(bytes int64) string {
+	return resource.NewQuantity(bytes, resource.BinarySI).String()
+}
+
+// QuantityToBytes parses a Kubernetes quantity (e.g. 50Gi) and returns its
+// value in bytes.
+func QuantityToBytes(s string) (int64, error) {
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse quantity %q: %v", s, err)
+	}
+	if q.Sign() < 0 {
+		return 0, fmt.Errorf("quantity %q must not be negative", s)
+	}
+	return q.Value(), nil
+}
+
+// supportedFsTypes are the filesystem types volumes can be formatted with.
+var supportedFsTypes = sets.NewString("ext4", "ext3", "xfs", "btrfs")
+
+// fsSpecificMountOptions maps mount options which are only understood by one
+// filesystem to that filesystem. Options are matched by name, without value.
+var fsSpecificMountOptions = map[string]string{
+	"prjquota":             "xfs",
+	"pquota":               "xfs",
+	"pqnoenforce":          "xfs",
+	"uqnoenforce":          "xfs",
+	"gqnoenforce":          "xfs",
+	"allocsize":            "xfs",
+	"inode64":              "xfs",
+	"nouuid":               "xfs",
+	"subvol":               "btrfs",
+	"subvolid":             "btrfs",
+	"compress":             "btrfs",
+	"compress-force":       "btrfs",
+	"space_cache":          "btrfs",
+	"autodefrag":           "btrfs",
+	"data":                 "ext",
+	"journal_checksum":     "ext",
+	"journal_async_commit": "ext",
+}
+
+// ValidateFsType returns the filesystem type to format and mount a volume with,
+// defaulting to ext4 when none or an unsupported one is requested, and
+// validates that the mount options are understood by that filesystem.
+func ValidateFsType(logger *zap.SugaredLogger, fsType string, mountOptions []string) (string, error) {
+	defaultFsType := "ext4"
+	switch {
+	case supportedFsTypes.Has(fsType):
+	case fsType == "":
+		//No fsType provided returning ext4
+		logger.With("fsType", defaultFsType).Info("No fsType provided, using the default.")
+		fsType = defaultFsType
+	default:
+		logger.With("fsType", fsType).Warn("Supporting only 'ext4/ext3/xfs/btrfs' as fsType.")
+		fsType = defaultFsType
+	}
+	if err := validateMountOptions(fsType, mountOptions); err != nil {
+		return "", err
+	}
+	return fsType, nil
+}
+
+// ValidateFsTypeStrict behaves like ValidateFsType, but returns an
+// InvalidArgument error for an unsupported fsType instead of falling back to
+// ext4, so that typos such as ex4 are reported. An empty fsType still defaults
+// to ext4.
+func ValidateFsTypeStrict(logger *zap.SugaredLogger, fsType string, mountOptions []string) (string, error) {
+	switch {
+	case supportedFsTypes.Has(fsType):
+	case fsType == "":
+		logger.With("fsType", "ext4").Info("No fsType provided, using the default.")
+		fsType = "ext4"
+	default:
+		return "", status.Errorf(codes.InvalidArgument, "unsupported fsType %q, supported values are %v", fsType, supportedFsTypes.List())
+	}
+	if err := validateMountOptions(fsType, mountOptions); err != nil {
+		return "", err
+	}
+	return fsType, nil
+}
+
+// validateMountOptions returns an InvalidArgument error if a mount option is
+// specific to a filesystem other than fsType.
+func validateMountOptions(fsType string, mountOptions []string) error {
+	family := fsType
+	if strings.HasPrefix(fsType, "ext") {
+		family = "ext"
+	}
+	for _, option := range mountOptions {
+		for _, o := range strings.Split(option, ",") {
+			name := strings.SplitN(strings.TrimSpace(o), "=", 2)[0]
+			if required, ok := fsSpecificMountOptions[name]; ok && required != family {
+				return status.Errorf(codes.InvalidArgument, "mount option %q is not supported for fsType %s", o, fsType)
+			}
+		}
+	}
+	return nil
+}
+
+// IsFsTypeSupportedByKernel reports whether fsType is listed in
+// ProcFilesystemsFile, i.e. whether the kernel can mount it. Filesystems built
+// as modules are only listed once their module is loaded.
+func IsFsTypeSupportedByKernel(fsType string) (bool, error) {
+	return isFsTypeSupportedByKernel(ProcFilesystemsFile, fsType)
+}
+
+func isFsTypeSupportedByKernel(path, fsType string) (bool, error) {
+	if fsType == "" {
+		return false, fmt.Errorf("fsType must be provided")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		// Lines are of the form "[nodev]\t<fsType>"
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[len(fields)-1] == fsType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FilesystemResizeSize returns the size argument to pass to the resize tool of
+// fsType for a device of deviceBytes. For ext3/ext4 this is the device size in
+// whole 4KiB filesystem blocks as expected by resize2fs. xfs_growfs always grows
+// to the whole device so -1 is returned for xfs.
+func FilesystemResizeSize(fsType string, deviceBytes int64) (int64, error) {
+	if deviceBytes <= 0 {
+		return 0, fmt.Errorf("invalid device size %d bytes", deviceBytes)
+	}
+	switch fsType {
+	case "ext3", "ext4":
+		blocks := deviceBytes / extResizeBlockSizeBytes
+		if blocks == 0 {
+			return 0, fmt.Errorf("device size %d bytes is smaller than a single %s block", deviceBytes, fsType)
+		}
+		return blocks, nil
+	case "xfs":
+		return -1, nil
+	default:
+		return 0, fmt.Errorf("resize is not supported for fsType %q", fsType)
+	}
+}
+
+// ValidateAccessTypeParams returns an error when a raw block volume is
+// requested together with a filesystem type, as the two are contradictory.
+func ValidateAccessTypeParams(isBlock bool, fsType string) error {
+	if isBlock && fsType != "" {
+		return status.Errorf(codes.InvalidArgument, "fsType %q can not be specified for a raw block volume", fsType)
+	}
+	return nil
+}
+
+// ValidateMountOptions returns an error listing every mount option that is on
+// the denylist. Options are compared by name, so a denylisted "uid" also
+// rejects "uid=0", and comma separated entries are checked individually.
+func ValidateMountOptions(opts []string, denylist []string) error {
+	denied := sets.NewString(denylist...)
+	var rejected []string
+	for _, opt := range opts {
+		for _, o := range strings.Split(opt, ",") {
+			o = strings.TrimSpace(o)
+			name := strings.SplitN(o, "=", 2)[0]
+			if denied.Has(name) {
+				rejected = append(rejected, o)
+			}
+		}
+	}
+	if len(rejected) > 0 {
+		return status.Errorf(codes.InvalidArgument, "mount options %v are not allowed", rejected)
+	}
+	return nil
+}
+
+type VolumeLocks struct {
+	locks sets.String
+	mux   sync.Mutex
+}
+
+func NewVolumeLocks() *VolumeLocks {
+	return &VolumeLocks{
+		locks: sets.NewString(),
+	}
+}
+
+func (vl *VolumeLocks) TryAcquire(volumeID string) bool {
+	vl.mux.Lock()
+	defer vl.mux.Unlock()
+	if vl.locks.Has(volumeID) {
+		return false
+	}
+	vl.locks.Insert(volumeID)
+	return true
+}
+
+func (vl *VolumeLocks) Release(volumeID string) {
+	vl.mux.Lock()
+	defer vl.mux.Unlock()
+	vl.locks.Delete(volumeID)
+}
+
+// SetMaximumVolumeSize overrides the maximum supported volume size. The value
+// must lie between MinimumVolumeSizeInBytes and AbsoluteMaximumVolumeSizeInBytes.
+func SetMaximumVolumeSize(bytes int64) error {
+	if bytes < MinimumVolumeSizeInBytes {
+		return fmt.Errorf("maximum volume size (%v) can not be less than minimum supported volume size (%v)", FormatBytes(bytes), FormatBytes(MinimumVolumeSizeInBytes))
+	}
+	if bytes > AbsoluteMaximumVolumeSizeInBytes {
+		return fmt.Errorf("maximum volume size (%v) can not exceed %v", FormatBytes(bytes), FormatBytes(AbsoluteMaximumVolumeSizeInBytes))
+	}
+	maximumVolumeSizeInBytesMux.Lock()
+	defer maximumVolumeSizeInBytesMux.Unlock()
+	maximumVolumeSizeInBytes = bytes
+	return nil
+}
+
+// GetMaximumVolumeSize returns the maximum supported volume size in bytes.
+func GetMaximumVolumeSize() int64 {
+	maximumVolumeSizeInBytesMux.RLock()
+	defer maximumVolumeSizeInBytesMux.RUnlock()
+	return maximumVolumeSizeInBytes
+}
+
+// extractStorage extracts the storage size in bytes from the given capacity
+// range. If the capacity range is not satisfied it returns the default volume
+// size. If the capacity range is below or above supported sizes, it returns an
+// error.
+func ExtractStorage(capRange *csi.CapacityRange) (int64, error) {
+	if capRange == nil {
+		return defaultVolumeSizeInBytes, nil
 	}
 
 	requiredBytes := capRange.GetRequiredBytes()
@@ -454,49 +1512,131 @@ func ExtractStorage(capRange *csi.CapacityRange) (int64, error) {
 		return defaultVolumeSizeInBytes, nil
 	}
 
-	if requiredSet && limitSet && limitBytes < requiredBytes {
-		return 0, fmt.Errorf("limit (%v) can not be less than required (%v) size", FormatBytes(limitBytes), FormatBytes(requiredBytes))
+	maximumVolumeSize := GetMaximumVolumeSize()
+
+	if requiredSet && requiredBytes > maximumVolumeSize {
+		return 0, fmt.Errorf("required (%v) can not exceed maximum supported volume size (%v)", FormatBytes(requiredBytes), FormatBytes(maximumVolumeSize))
 	}
 
-	if requiredSet && !limitSet {
-		return MaxOfInt(requiredBytes, MinimumVolumeSizeInBytes), nil
+	if limitSet && limitBytes > maximumVolumeSize {
+		return 0, fmt.Errorf("limit (%v) can not exceed maximum supported volume size (%v)", FormatBytes(limitBytes), FormatBytes(maximumVolumeSize))
+	}
+
+	if requiredSet && limitSet && limitBytes < requiredBytes {
+		return 0, fmt.Errorf("limit (%v) can not be less than required (%v) size", FormatBytes(limitBytes), FormatBytes(requiredBytes))
 	}
 
 	if limitSet {
 		return MaxOfInt(limitBytes, MinimumVolumeSizeInBytes), nil
 	}
 
-	if requiredSet && requiredBytes > MaximumVolumeSizeInBytes {
-		return 0, fmt.Errorf("required (%v) can not exceed maximum supported volume size (%v)", FormatBytes(requiredBytes), FormatBytes(MaximumVolumeSizeInBytes))
-	}
+	return MaxOfInt(requiredBytes, MinimumVolumeSizeInBytes), nil
+}
 
-	if !requiredSet && limitSet && limitBytes > MaximumVolumeSizeInBytes {
-		return 0, fmt.Errorf("limit (%v) can not exceed maximum supported volume size (%v)", FormatBytes(limitBytes), FormatBytes(MaximumVolumeSizeInBytes))
+// ExtractStorageFSS returns the size requested by the capacity range of an FSS
+// volume. File systems are elastic, so unlike ExtractStorage neither the block
+// volume minimum nor maximum size applies. 0 is returned, meaning elastic,
+// when no size is requested.
+func ExtractStorageFSS(capRange *csi.CapacityRange) (int64, error) {
+	if capRange == nil {
+		return 0, nil
 	}
 
-	if requiredSet && limitSet {
-		return MaxOfInt(requiredBytes, limitBytes), nil
+	requiredBytes := capRange.GetRequiredBytes()
+	limitBytes := capRange.GetLimitBytes()
+	if requiredBytes < 0 || limitBytes < 0 {
+		return 0, fmt.Errorf("required (%v) and limit (%v) sizes can not be negative", requiredBytes, limitBytes)
+	}
+	if requiredBytes > 0 && limitBytes > 0 && limitBytes < requiredBytes {
+		return 0, fmt.Errorf("limit (%v) can not be less than required (%v) size", FormatBytes(limitBytes), FormatBytes(requiredBytes))
 	}
 
-	if requiredSet {
+	if requiredBytes > 0 {
 		return requiredBytes, nil
 	}
+	return limitBytes, nil
+}
 
-	if limitSet {
-		return limitBytes, nil
+// CapacityRangeSatisfiedBy returns whether an existing volume of actualBytes
+// already satisfies the capacity range, i.e. no expansion is needed.
+func CapacityRangeSatisfiedBy(capRange *csi.CapacityRange, actualBytes int64) bool {
+	if capRange == nil {
+		return true
+	}
+	requiredBytes := capRange.GetRequiredBytes()
+	limitBytes := capRange.GetLimitBytes()
+
+	if requiredBytes > 0 && actualBytes < requiredBytes {
+		return false
+	}
+	if limitBytes > 0 && actualBytes > limitBytes {
+		return false
+	}
+	return true
+}
+
+// ValidateAgainstSoftCap returns an error when the requested size exceeds the
+// soft cap configured for the storage class. A soft cap of 0 or less means no
+// cap is configured.
+func ValidateAgainstSoftCap(requestedBytes, softCapBytes int64) error {
+	if softCapBytes <= 0 {
+		return nil
 	}
+	if requestedBytes > softCapBytes {
+		return fmt.Errorf("requested size (%v) exceeds the configured soft cap (%v)", FormatBytes(requestedBytes), FormatBytes(softCapBytes))
+	}
+	return nil
+}
 
-	return defaultVolumeSizeInBytes, nil
+// ValidateNotShrinking returns an error if the requested size is smaller than
+// the current size of the volume, as volumes can not be shrunk.
+func ValidateNotShrinking(currentBytes, requestedBytes int64) error {
+	if requestedBytes < currentBytes {
+		return fmt.Errorf("requested size (%v) can not be less than current volume size (%v), volumes can not be shrunk", FormatBytes(requestedBytes), FormatBytes(currentBytes))
+	}
+	return nil
 }
 
 func RoundUpSize(volumeSizeBytes int64, allocationUnitBytes int64) int64 {
 	return (volumeSizeBytes + allocationUnitBytes - 1) / allocationUnitBytes
 }
 
+// DefaultVolumeSizeBytes returns the size in bytes used when a volume is
+// requested without a capacity range.
+func DefaultVolumeSizeBytes() int64 {
+	return defaultVolumeSizeInBytes
+}
+
+// DefaultVolumeSizeGiB returns the default volume size in GiB.
+func DefaultVolumeSizeGiB() int64 {
+	return RoundUpSize(defaultVolumeSizeInBytes, 1*client.GiB)
+}
+
 func RoundUpMinSize() int64 {
 	return RoundUpSize(MinimumVolumeSizeInBytes, 1*client.GiB)
 }
 
+// ToOCIAlignedBytes rounds the given size up to the next whole GiB, the
+// granularity OCI block volumes are created in.
+func ToOCIAlignedBytes(bytes int64) int64 {
+	if bytes <= 0 {
+		return 0
+	}
+	return RoundUpSize(bytes, client.GiB) * client.GiB
+}
+
+// ValidateOCIAlignedBytes returns an error unless the given size is a positive
+// whole number of GiB, as required by OCI CreateVolume.
+func ValidateOCIAlignedBytes(bytes int64) error {
+	if bytes <= 0 {
+		return fmt.Errorf("volume size (%v) must be positive", bytes)
+	}
+	if bytes%client.GiB != 0 {
+		return fmt.Errorf("volume size (%v) is not a whole number of GiB", FormatBytes(bytes))
+	}
+	return nil
+}
+
 func IsFipsEnabled() (string, error) {
 	command := exec.Command(CAT_COMMAND, FIPS_ENABLED_FILE_PATH)
 	output, err := command.CombinedOutput()
@@ -506,6 +1646,28 @@ func IsFipsEnabled() (string, error) {
 
 	return string(output), nil
 }
+
+// EnforceFipsForEncryptedVolume returns an error when in-transit encryption is
+// requested on a node without FIPS mode enabled, so that clusters requiring
+// FIPS do not stage encrypted volumes with a non-compliant stunnel.
+func EnforceFipsForEncryptedVolume(encrypted bool) error {
+	return enforceFipsForEncryptedVolume(encrypted, IsFipsEnabled)
+}
+
+func enforceFipsForEncryptedVolume(encrypted bool, isFipsEnabled func() (string, error)) error {
+	if !encrypted {
+		return nil
+	}
+	content, err := isFipsEnabled()
+	if err != nil {
+		return status.Errorf(codes.Internal, "could not verify if FIPS is enabled: %v", err)
+	}
+	if strings.TrimSpace(content) != "1" {
+		return status.Error(codes.FailedPrecondition, "FIPS mode must be enabled on the node for in-transit encrypted volumes")
+	}
+	return nil
+}
+
 func IsInTransitEncryptionPackageInstalled() (bool, error) {
 	args := []string{"-q", InTransitEncryptionPackageName}
 	command := exec.Command(RPM_COMMAND, args...)
@@ -524,6 +1686,34 @@ func IsInTransitEncryptionPackageInstalled() (bool, error) {
 	return false, nil
 }
 
+// SupportsRawBlock reports whether raw block volumes can be served to pods on
+// this node. kubelet pins the device of every raw block volume it maps with a
+// loop device, and the driver sizes raw block devices with blockdev, so both
+// the loop driver and the blockdev binary must be available.
+func SupportsRawBlock() (bool, error) {
+	return supportsRawBlock(os.Stat, exec.LookPath)
+}
+
+func supportsRawBlock(stat func(name string) (os.FileInfo, error), lookPath func(file string) (string, error)) (bool, error) {
+	info, err := stat(loopControlPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check loop device support at %s: %v", loopControlPath, err)
+	}
+	if info.Mode()&os.ModeCharDevice == 0 {
+		return false, nil
+	}
+	if _, err := lookPath("blockdev"); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to look up blockdev: %v", err)
+	}
+	return true, nil
+}
+
 func GetBlockSizeBytes(logger *zap.SugaredLogger, devicePath string) (int64, error) {
 	args := []string{"--getsize64", devicePath}
 	cmd := exec.Command("blockdev", args...)
@@ -540,14 +1730,102 @@ func GetBlockSizeBytes(logger *zap.SugaredLogger, devicePath string) (int64, err
 	return gotSizeBytes, nil
 }
 
+// IsDeviceReadOnly reports whether the block layer marks the device read-only,
+// as reported by blockdev --getro, so that a rw mount of a read-only device
+// can fail with a clear error.
+func IsDeviceReadOnly(logger *zap.SugaredLogger, devicePath string) (bool, error) {
+	return isDeviceReadOnly(logger, NewCommandRunner(), devicePath)
+}
+
+func isDeviceReadOnly(logger *zap.SugaredLogger, runner CommandRunner, devicePath string) (bool, error) {
+	output, err := runner.Run("blockdev", "--getro", devicePath)
+	if err != nil {
+		return false, fmt.Errorf("blockdev --getro failed on %s: %v, output: %s", devicePath, err, string(output))
+	}
+	strOut := strings.TrimSpace(string(output))
+	logger.With("devicePath", devicePath, "command", "blockdev", "output", strOut).Debugf("Get block device read-only state successful")
+	switch strOut {
+	case "1":
+		return true, nil
+	case "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to parse read-only state %q of %s", strOut, devicePath)
+}
+
 func ValidateDNSName(name string) bool {
 	pattern := `^([a-zA-Z0-9]+(-[a-zA-Z0-9]+)*\.)+[a-zA-Z]{2,}$`
 	match, _ := regexp.MatchString(pattern, name)
 	return match
 }
 
+// StripOCIScheme removes a leading oci:// from provider IDs and volume handles.
+// Inputs without the scheme are returned unchanged.
+func StripOCIScheme(s string) string {
+	return client.MapProviderIDToInstanceID(s)
+}
+
+// VolumeHandleHash returns a short stable hash of the volume handle, 16 hex
+// characters long, to be used as a metric label in place of the volume handle
+// so that label cardinality stays bounded. Logs should keep the full handle.
+func VolumeHandleHash(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:volumeHandleHashBytes])
+}
+
+// ValidateMountTargetInCIDRs checks that the mount target IP lies within at
+// least one of the allowed CIDRs. Bracketed IPv6 addresses are accepted.
+func ValidateMountTargetInCIDRs(ip string, allowedCIDRs []string) error {
+	mountTargetIp := net.ParseIP(strings.Trim(ip, "[]"))
+	if mountTargetIp == nil {
+		return fmt.Errorf("invalid mount target IP %s", ip)
+	}
+	for _, cidr := range allowedCIDRs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return fmt.Errorf("invalid allowed CIDR %s: %v", cidr, err)
+		}
+		if ipNet.Contains(mountTargetIp) {
+			return nil
+		}
+	}
+	return fmt.Errorf("mount target IP %s is not in any of the allowed CIDRs %v", ip, allowedCIDRs)
+}
+
+// ValidateExportPath checks that the FSS export path is rooted at the file
+// system root, i.e. starts with a slash and contains no relative segments.
+func ValidateExportPath(export string) error {
+	if export == "" {
+		return fmt.Errorf("export path must be provided")
+	}
+	if !strings.HasPrefix(export, "/") {
+		return fmt.Errorf("invalid export path %s, must start with /", export)
+	}
+	for _, segment := range strings.Split(export[1:], "/") {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("invalid export path %s, must not contain relative segments", export)
+		}
+	}
+	return nil
+}
+
+// ValidateOCIDOfType checks that the given OCID identifies a resource of the
+// given type. The resource type is the second dot separated field of an OCID,
+// e.g. filesystem in ocid1.filesystem.oc1.phx.<unique ID>.
+func ValidateOCIDOfType(ocid, resourceType string) error {
+	parts := strings.Split(ocid, ".")
+	if len(parts) < 3 {
+		return fmt.Errorf("invalid OCID %q", ocid)
+	}
+	if parts[1] != resourceType {
+		return fmt.Errorf("OCID %q is of type %s, expected %s", ocid, parts[1], resourceType)
+	}
+	return nil
+}
+
 func ValidateFssId(id string) *FSSVolumeHandler {
 	volumeHandler := &FSSVolumeHandler{"", "", ""}
+	id = StripOCIScheme(id)
 	if id == "" {
 		return volumeHandler
 	}
@@ -556,7 +1834,8 @@ func ValidateFssId(id string) *FSSVolumeHandler {
 	lastColon := strings.LastIndex(id, ":")
 	if firstColon > 0 && lastColon < len(id)-1 && firstColon != lastColon {
 		//To handle ipv6  ex.[fd00:00c1::a9fe:202] trim brackets to get fd00:00c1::a9fe:202 which is parsable
-		if net.ParseIP(strings.Trim(id[firstColon+1:lastColon], "[]")) != nil || ValidateDNSName(id[firstColon+1:lastColon]) {
+		if (net.ParseIP(strings.Trim(id[firstColon+1:lastColon], "[]")) != nil || ValidateDNSName(id[firstColon+1:lastColon])) &&
+			ValidateExportPath(id[lastColon+1:]) == nil && ValidateOCIDOfType(id[:firstColon], "filesystem") == nil {
 			volumeHandler.FilesystemOcid = id[:firstColon]
 			volumeHandler.MountTargetIPAddress = id[firstColon+1 : lastColon]
 			volumeHandler.FsExportPath = id[lastColon+1:]
@@ -580,15 +1859,133 @@ func GetIsFeatureEnabledFromEnv(logger *zap.SugaredLogger, featureName string, d
 	return enableFeature
 }
 
+// VolumeLogFields returns the canonical volumeID, nodeId and stagingPath
+// key/value pairs to be passed to SugaredLogger.With. Empty values are omitted.
+func VolumeLogFields(volumeID, nodeID, stagingPath string) []interface{} {
+	fields := make([]interface{}, 0, 6)
+	if volumeID != "" {
+		fields = append(fields, "volumeID", volumeID)
+	}
+	if nodeID != "" {
+		fields = append(fields, "nodeId", nodeID)
+	}
+	if stagingPath != "" {
+		fields = append(fields, "stagingPath", stagingPath)
+	}
+	return fields
+}
+
+// SetIscsiIpv6Prefix overrides the prefix ConvertIscsiIpFromIpv4ToIpv6 uses to
+// build IPv6 iSCSI addresses. The prefix is either an address such as
+// fd00:00c1:: or a network such as fd00:c1::/96, and must leave the last 32
+// bits free for the IPv4 address. An empty prefix restores IscsiIpv6Prefix.
+func SetIscsiIpv6Prefix(prefix string) error {
+	parsed := IscsiIpv6Prefix
+	if prefix != "" {
+		var err error
+		if parsed, err = parseIscsiIpv6Prefix(prefix); err != nil {
+			return err
+		}
+	}
+	iscsiIpv6PrefixMux.Lock()
+	defer iscsiIpv6PrefixMux.Unlock()
+	iscsiIpv6Prefix = parsed
+	return nil
+}
+
+// GetIscsiIpv6Prefix returns the prefix used to build IPv6 iSCSI addresses.
+func GetIscsiIpv6Prefix() string {
+	iscsiIpv6PrefixMux.RLock()
+	defer iscsiIpv6PrefixMux.RUnlock()
+	return iscsiIpv6Prefix
+}
+
+// parseIscsiIpv6Prefix validates that the given prefix is an IPv6 network of
+// length /96 or shorter and returns its network address.
+func parseIscsiIpv6Prefix(prefix string) (string, error) {
+	ip, ones := net.ParseIP(prefix), 96
+	if strings.Contains(prefix, "/") {
+		var ipNet *net.IPNet
+		var err error
+		ip, ipNet, err = net.ParseCIDR(prefix)
+		if err != nil {
+			return "", fmt.Errorf("invalid iSCSI ipv6 prefix %s: %v", prefix, err)
+		}
+		ones, _ = ipNet.Mask.Size()
+	}
+	if ip == nil || ip.To4() != nil {
+		return "", fmt.Errorf("invalid iSCSI ipv6 prefix %s, must be an ipv6 network", prefix)
+	}
+	if ones > 96 {
+		return "", fmt.Errorf("invalid iSCSI ipv6 prefix %s, must be /96 or shorter", prefix)
+	}
+	if !ip.Equal(ip.Mask(net.CIDRMask(ones, 128))) {
+		return "", fmt.Errorf("invalid iSCSI ipv6 prefix %s, host bits must be zero", prefix)
+	}
+	return ip.String(), nil
+}
+
 func ConvertIscsiIpFromIpv4ToIpv6(ipv4IscsiIp string) (string, error) {
+	return convertIscsiIpFromIpv4ToIpv6(GetIscsiIpv6Prefix(), ipv4IscsiIp)
+}
+
+func convertIscsiIpFromIpv4ToIpv6(ipv6Prefix string, ipv4IscsiIp string) (string, error) {
 	ipv4IscsiIP := net.ParseIP(ipv4IscsiIp).To4()
 	if ipv4IscsiIP == nil {
 		return "", fmt.Errorf("invalid iSCSIIp identified %s", ipv4IscsiIp)
 	}
-	ipv6IscsiIp := net.ParseIP(IscsiIpv6Prefix)
-	ipv6IscsiIpBytes := ipv6IscsiIp.To16()
-	copy(ipv6IscsiIpBytes[12:], ipv4IscsiIP.To4())
-	return ipv6IscsiIpBytes.String(), nil
+	ipv6IscsiIpBytes := net.ParseIP(ipv6Prefix).To16()
+	if ipv6IscsiIpBytes == nil {
+		return "", fmt.Errorf("invalid iSCSI ipv6 prefix %s", ipv6Prefix)
+	}
+	copy(ipv6IscsiIpBytes[12:], ipv4IscsiIP)
+
+	// A prefix such as ::ffff:0:0 maps the address back into IPv4, so make sure
+	// the result is still a proper IPv6 address.
+	ipv6IscsiIp := ipv6IscsiIpBytes.String()
+	if parsed := net.ParseIP(ipv6IscsiIp); parsed == nil || parsed.To4() != nil {
+		return "", fmt.Errorf("iSCSIIp %s converted with prefix %s to invalid ipv6 address %s", ipv4IscsiIp, ipv6Prefix, ipv6IscsiIp)
+	}
+	return ipv6IscsiIp, nil
+}
+
+// BuildIpv6Portal converts the given IPv4 iSCSI IP to its IPv6 equivalent and
+// returns the portal to connect to in the bracketed [ip]:port form.
+func BuildIpv6Portal(ipv4Ip string, port int) (string, error) {
+	if port <= 0 || port > 65535 {
+		return "", fmt.Errorf("invalid iSCSI port %d", port)
+	}
+	ipv6Ip, err := ConvertIscsiIpFromIpv4ToIpv6(ipv4Ip)
+	if err != nil {
+		return "", err
+	}
+	return (&disk.Disk{IscsiIp: ipv6Ip, Port: port}).Target(), nil
+}
+
+// ConvertIscsiIpsToIpv6 converts each of the given IPv4 iSCSI portal IPs to
+// its IPv6 equivalent. Conversion errors are aggregated and returned together.
+// Portals which convert to an IPv6 address already produced are rejected.
+func ConvertIscsiIpsToIpv6(ipv4Ips []string) ([]string, error) {
+	ipv6Ips := make([]string, 0, len(ipv4Ips))
+	convertedFrom := make(map[string]string, len(ipv4Ips))
+	var errs []error
+	for _, ipv4Ip := range ipv4Ips {
+		ipv6Ip, err := ConvertIscsiIpFromIpv4ToIpv6(ipv4Ip)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if previous, ok := convertedFrom[ipv6Ip]; ok {
+			errs = append(errs, fmt.Errorf("iSCSIIp %s and %s both convert to %s", previous, ipv4Ip, ipv6Ip))
+			continue
+		}
+		convertedFrom[ipv6Ip] = ipv4Ip
+		ipv6Ips = append(ipv6Ips, ipv6Ip)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to convert iSCSI IPs to IPv6: %v", errors.Join(errs...))
+	}
+	return ipv6Ips, nil
 }
 
 func FormatValidIp(ipAddress string) string {
@@ -600,6 +1997,39 @@ func FormatValidIp(ipAddress string) string {
 	return ipAddress
 }
 
+// ExtractFssExportFromMount parses the NFS source (server:/export) of a
+// /proc/mounts line and returns the server and export path. IPv6 servers may
+// be bracketed, as produced by FormatValidIp, and are returned without brackets.
+func ExtractFssExportFromMount(mountLine string) (server, export string, err error) {
+	fields := strings.Fields(mountLine)
+	if len(fields) == 0 {
+		return "", "", fmt.Errorf("empty mount line")
+	}
+	source := fields[0]
+
+	if strings.HasPrefix(source, "[") {
+		end := strings.Index(source, "]:")
+		if end == -1 {
+			return "", "", fmt.Errorf("invalid NFS source %q: missing closing bracket", source)
+		}
+		server, export = source[1:end], source[end+2:]
+	} else {
+		sep := strings.Index(source, ":/")
+		if sep == -1 {
+			return "", "", fmt.Errorf("invalid NFS source %q: expected server:/export", source)
+		}
+		server, export = source[:sep], source[sep+1:]
+	}
+
+	if server == "" {
+		return "", "", fmt.Errorf("invalid NFS source %q: missing server", source)
+	}
+	if !strings.HasPrefix(export, "/") {
+		return "", "", fmt.Errorf("invalid NFS source %q: export path must be absolute", source)
+	}
+	return server, export, nil
+}
+
 func FormatValidIpStackInK8SConvention(ipStack string) string {
 	if strings.EqualFold(ipStack, Ipv4Stack) {
 		return Ipv4Stack
@@ -617,6 +2047,19 @@ func IsIpv6(ipAddress string) bool {
 	return net.ParseIP(ipAddress).To4() == nil && net.ParseIP(strings.Trim(ipAddress, "[]")).To16() != nil
 }
 
+// DetectAddressFamilies reports whether the given addresses contain at least
+// one IPv4 and at least one IPv6 address. Unparseable addresses are ignored.
+func DetectAddressFamilies(addrs []string) (hasV4 bool, hasV6 bool) {
+	for _, addr := range addrs {
+		if IsIpv4(addr) {
+			hasV4 = true
+		} else if IsIpv6(addr) {
+			hasV6 = true
+		}
+	}
+	return hasV4, hasV6
+}
+
 func IsIpv4SingleStackSubnet(subnet *core.Subnet) bool {
 	return !IsDualStackSubnet(subnet) && subnet.CidrBlock != nil && len(*subnet.CidrBlock) > 0 && !strings.Contains(*subnet.CidrBlock, "null")
 }
@@ -641,6 +2084,75 @@ func IsIpv6SingleStackNode(nodeMetadata *NodeMetadata) bool {
 	return nodeMetadata.Ipv6Enabled == true && nodeMetadata.Ipv4Enabled == false
 }
 
+func IsIpv4SingleStackNode(nodeMetadata *NodeMetadata) bool {
+	if nodeMetadata == nil {
+		return false
+	}
+	return nodeMetadata.Ipv4Enabled == true && nodeMetadata.Ipv6Enabled == false
+}
+
+func IsDualStackNode(nodeMetadata *NodeMetadata) bool {
+	if nodeMetadata == nil {
+		return false
+	}
+	return nodeMetadata.Ipv4Enabled == true && nodeMetadata.Ipv6Enabled == true
+}
+
+// ResolveAttachIpFamily returns the IP family to attach iSCSI volumes over. A
+// single stack node uses its only family; a dual stack node uses its preferred
+// family and falls back to IPv4 when no preference is set.
+func ResolveAttachIpFamily(nodeMetadata *NodeMetadata) (string, error) {
+	if nodeMetadata == nil {
+		return "", fmt.Errorf("node metadata must be provided to resolve the attach ip family")
+	}
+	switch {
+	case IsIpv4SingleStackNode(nodeMetadata):
+		return Ipv4Stack, nil
+	case IsIpv6SingleStackNode(nodeMetadata):
+		return Ipv6Stack, nil
+	case !IsDualStackNode(nodeMetadata):
+		return "", fmt.Errorf("node has neither ipv4 nor ipv6 enabled")
+	}
+
+	switch preferred := FormatValidIpStackInK8SConvention(nodeMetadata.PreferredNodeIpFamily); preferred {
+	case "":
+		return Ipv4Stack, nil
+	case Ipv4Stack, Ipv6Stack:
+		return preferred, nil
+	default:
+		return "", fmt.Errorf("invalid preferred node ip family %q", nodeMetadata.PreferredNodeIpFamily)
+	}
+}
+
+// ValidateMountTargetFamily rejects a mount target whose IP family is not
+// enabled on the node, as mounting over it would hang. Mount targets given by
+// DNS name are not validated.
+func ValidateMountTargetFamily(mountTargetIp string, nodeMetadata *NodeMetadata) error {
+	if nodeMetadata == nil {
+		return nil
+	}
+	if IsIpv4(mountTargetIp) && !nodeMetadata.Ipv4Enabled {
+		return status.Error(codes.InvalidArgument, "Ipv4 mount target identified in volume id, but worker node does not support ipv4 ip family.")
+	} else if IsIpv6(mountTargetIp) && !nodeMetadata.Ipv6Enabled {
+		return status.Error(codes.InvalidArgument, "Ipv6 mount target identified in volume id, but worker node does not support ipv6 ip family.")
+	}
+	return nil
+}
+
+// ValidateEncryptionWithIpFamily rejects in-transit encryption on nodes where it
+// is not supported. The oci-fss-utils stunnel proxy only connects to mount
+// targets over IPv4, so encrypted mounts need an IPv4 enabled node; IPv6 single
+// stack nodes are rejected. Unencrypted mounts are always allowed.
+func ValidateEncryptionWithIpFamily(encrypted bool, nodeMetadata *NodeMetadata) error {
+	if !encrypted || nodeMetadata == nil {
+		return nil
+	}
+	if IsIpv6SingleStackNode(nodeMetadata) {
+		return status.Error(codes.InvalidArgument, "In-transit encryption is not supported on ipv6 single stack worker nodes.")
+	}
+	return nil
+}
+
 func LoadCSIConfigFromConfigMap(csiConfig *CSIConfig, k kubernetes.Interface, configMapName string, logger *zap.SugaredLogger) {
 	// Get the ConfigMap
 	// Parse the configuration for each driver
@@ -18,16 +18,27 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/oracle/oci-cloud-controller-manager/pkg/oci/client"
 	"github.com/oracle/oci-cloud-controller-manager/pkg/util"
+	"github.com/oracle/oci-cloud-controller-manager/pkg/util/disk"
 	"github.com/oracle/oci-go-sdk/v65/core"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/utils/pointer"
 )
@@ -76,13 +87,15 @@ func TestUtil_getAvailableDomainInNodeLabel(t *testing.T) {
 
 func Test_validateFsType(t *testing.T) {
 	type args struct {
-		logger *zap.SugaredLogger
-		fsType string
+		logger       *zap.SugaredLogger
+		fsType       string
+		mountOptions []string
 	}
 	tests := []struct {
-		name string
-		args args
-		want string
+		name    string
+		args    args
+		want    string
+		wantErr bool
 	}{
 		{
 			name: "Return ext4",
@@ -108,6 +121,14 @@ func Test_validateFsType(t *testing.T) {
 			},
 			want: "xfs",
 		},
+		{
+			name: "Return btrfs",
+			args: args{
+				logger: zap.S(),
+				fsType: "btrfs",
+			},
+			want: "btrfs",
+		},
 		{
 			name: "Return default ext4 for empty string",
 			args: args{
@@ -124,16 +145,168 @@ func Test_validateFsType(t *testing.T) {
 			},
 			want: "ext4",
 		},
+		{
+			name: "Generic mount options are accepted",
+			args: args{
+				logger:       zap.S(),
+				fsType:       "ext4",
+				mountOptions: []string{"noatime", "nodev,nosuid"},
+			},
+			want: "ext4",
+		},
+		{
+			name: "prjquota with xfs",
+			args: args{
+				logger:       zap.S(),
+				fsType:       "xfs",
+				mountOptions: []string{"prjquota"},
+			},
+			want: "xfs",
+		},
+		{
+			name: "prjquota with ext4",
+			args: args{
+				logger:       zap.S(),
+				fsType:       "ext4",
+				mountOptions: []string{"prjquota"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "prjquota with the default fsType",
+			args: args{
+				logger:       zap.S(),
+				fsType:       "",
+				mountOptions: []string{"noatime,prjquota"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "btrfs subvolume with btrfs",
+			args: args{
+				logger:       zap.S(),
+				fsType:       "btrfs",
+				mountOptions: []string{"subvol=data", "compress=zstd"},
+			},
+			want: "btrfs",
+		},
+		{
+			name: "btrfs compression with xfs",
+			args: args{
+				logger:       zap.S(),
+				fsType:       "xfs",
+				mountOptions: []string{"compress=zstd"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "ext journal mode with ext3",
+			args: args{
+				logger:       zap.S(),
+				fsType:       "ext3",
+				mountOptions: []string{"data=ordered"},
+			},
+			want: "ext3",
+		},
+		{
+			name: "ext journal mode with btrfs",
+			args: args{
+				logger:       zap.S(),
+				fsType:       "btrfs",
+				mountOptions: []string{"data=journal"},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := ValidateFsType(tt.args.logger, tt.args.fsType); got != tt.want {
+			got, err := ValidateFsType(tt.args.logger, tt.args.fsType, tt.args.mountOptions)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateFsType() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
 				t.Errorf("validateFsType() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func Test_ValidateFsTypeStrict(t *testing.T) {
+	tests := []struct {
+		name         string
+		fsType       string
+		mountOptions []string
+		want         string
+		wantCode     codes.Code
+	}{
+		{
+			name:     "Supported fsType",
+			fsType:   "xfs",
+			want:     "xfs",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "btrfs",
+			fsType:   "btrfs",
+			want:     "btrfs",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Empty fsType defaults to ext4",
+			fsType:   "",
+			want:     "ext4",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Typo is rejected",
+			fsType:   "ex4",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Unsupported fsType is rejected",
+			fsType:   "ntfs",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Mount options of the fsType",
+			fsType:       "xfs",
+			mountOptions: []string{"nouuid"},
+			want:         "xfs",
+			wantCode:     codes.OK,
+		},
+		{
+			name:         "Mount options of another fsType",
+			fsType:       "xfs",
+			mountOptions: []string{"data=ordered"},
+			wantCode:     codes.InvalidArgument,
+		},
+		{
+			name:         "Mount options are validated against the default fsType",
+			fsType:       "",
+			mountOptions: []string{"nouuid"},
+			wantCode:     codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateFsTypeStrict(zap.S(), tt.fsType, tt.mountOptions)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("ValidateFsTypeStrict() code = %v, want %v, error = %v", code, tt.wantCode, err)
+			}
+			if got != tt.want {
+				t.Errorf("ValidateFsTypeStrict() = %v, want %v", got, tt.want)
+			}
+			if tt.wantCode != codes.OK && tt.mountOptions == nil {
+				// The lenient variant keeps defaulting for backward compatibility.
+				if lenient, err := ValidateFsType(zap.S(), tt.fsType, nil); err != nil || lenient != "ext4" {
+					t.Errorf("ValidateFsType() = (%v, %v), want (ext4, nil)", lenient, err)
+				}
+			}
+		})
+	}
+}
+
 func Test_ValidateFssId(t *testing.T) {
 	tests := []struct {
 		name                 string
@@ -158,6 +331,15 @@ func Test_ValidateFssId(t *testing.T) {
 				FsExportPath:         "/FileSystem-Test",
 			},
 		},
+		{
+			name:         "Volume handle prefixed with oci scheme",
+			volumeHandle: "oci://ocid1.filesystem.oc1.phx.aaaaaaaaaahjpdudobuhqllqojxwiotqnb4c2ylefuzaaaaa:10.0.2.44:/FileSystem-Test",
+			wantFssVolumeHandler: &FSSVolumeHandler{
+				FilesystemOcid:       "ocid1.filesystem.oc1.phx.aaaaaaaaaahjpdudobuhqllqojxwiotqnb4c2ylefuzaaaaa",
+				MountTargetIPAddress: "10.0.2.44",
+				FsExportPath:         "/FileSystem-Test",
+			},
+		},
 		{
 			name:                 "Invalid Ipv4 provided in volume handle",
 			volumeHandle:         "ocid1.filesystem.oc1.phx.aaaaaaaaaahjpdudobuhqllqojxwiotqnb4c2ylefuzaaaaa:10.0.2:/FileSystem-Test",
@@ -221,6 +403,21 @@ func Test_ValidateFssId(t *testing.T) {
 			volumeHandle:         "ocid1.filesystem.oc1.phx.aaaaaaaaaahjpdudobuhqllqojxwiotqnb4c2ylefuzaaaaa:Invalid Dns:/FileSystem-Test",
 			wantFssVolumeHandler: &FSSVolumeHandler{},
 		},
+		{
+			name:                 "Invalid volumeHandle with relative export path",
+			volumeHandle:         "ocid1.filesystem.oc1.phx.aaaaaaaaaahjpdudobuhqllqojxwiotqnb4c2ylefuzaaaaa:10.0.2.44:/FileSystem-Test/../other",
+			wantFssVolumeHandler: &FSSVolumeHandler{},
+		},
+		{
+			name:                 "Invalid volumeHandle with a block volume ocid",
+			volumeHandle:         "ocid1.volume.oc1.phx.abyhqljrgvttnlx73nmrwfaux7kcvzfs3s66izvxf2h4lgvyndsdsnoiwr5q:10.0.2.44:/FileSystem-Test",
+			wantFssVolumeHandler: &FSSVolumeHandler{},
+		},
+		{
+			name:                 "Invalid volumeHandle with a malformed ocid",
+			volumeHandle:         "filesystem:10.0.2.44:/FileSystem-Test",
+			wantFssVolumeHandler: &FSSVolumeHandler{},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -234,6 +431,51 @@ func Test_ValidateFssId(t *testing.T) {
 	}
 }
 
+func Test_ValidateOCIDOfType(t *testing.T) {
+	tests := []struct {
+		name         string
+		ocid         string
+		resourceType string
+		wantErr      bool
+	}{
+		{
+			name:         "Filesystem ocid",
+			ocid:         "ocid1.filesystem.oc1.phx.aaaaaaaaaahjpdudobuhqllqojxwiotqnb4c2ylefuzaaaaa",
+			resourceType: "filesystem",
+		},
+		{
+			name:         "Volume ocid where a filesystem is expected",
+			ocid:         "ocid1.volume.oc1.phx.abyhqljrgvttnlx73nmrwfaux7kcvzfs3s66izvxf2h4lgvyndsdsnoiwr5q",
+			resourceType: "filesystem",
+			wantErr:      true,
+		},
+		{
+			name:         "Mount target ocid where a filesystem is expected",
+			ocid:         "ocid1.mounttarget.oc1.phx.aaaaaaaaaahjpdudobuhqllqojxwiotqnb4c2ylefuzaaaaa",
+			resourceType: "filesystem",
+			wantErr:      true,
+		},
+		{
+			name:         "Malformed ocid",
+			ocid:         "filesystem",
+			resourceType: "filesystem",
+			wantErr:      true,
+		},
+		{
+			name:         "Empty ocid",
+			resourceType: "filesystem",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateOCIDOfType(tt.ocid, tt.resourceType); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOCIDOfType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func Test_ConvertIscsiIpFromIpv4ToIpv6(t *testing.T) {
 
 	tests := []struct {
@@ -279,6 +521,188 @@ func Test_ConvertIscsiIpFromIpv4ToIpv6(t *testing.T) {
 	}
 }
 
+func Test_convertIscsiIpFromIpv4ToIpv6(t *testing.T) {
+	tests := []struct {
+		name        string
+		ipv6Prefix  string
+		ipv4IscsiIp string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "Default prefix",
+			ipv6Prefix:  IscsiIpv6Prefix,
+			ipv4IscsiIp: "169.254.2.2",
+			want:        "fd00:c1::a9fe:202",
+		},
+		{
+			name:        "Unparseable prefix",
+			ipv6Prefix:  "fd00:zz::",
+			ipv4IscsiIp: "169.254.2.2",
+			wantErr:     true,
+		},
+		{
+			name:        "Empty prefix",
+			ipv6Prefix:  "",
+			ipv4IscsiIp: "169.254.2.2",
+			wantErr:     true,
+		},
+		{
+			name:        "Ipv4 mapped prefix converts back to ipv4",
+			ipv6Prefix:  "::ffff:0:0",
+			ipv4IscsiIp: "169.254.2.2",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertIscsiIpFromIpv4ToIpv6(tt.ipv6Prefix, tt.ipv4IscsiIp)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("convertIscsiIpFromIpv4ToIpv6() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("convertIscsiIpFromIpv4ToIpv6() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_SetIscsiIpv6Prefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		prefix      string
+		ipv4IscsiIp string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "Default prefix when unset",
+			prefix:      "",
+			ipv4IscsiIp: "169.254.2.2",
+			want:        "fd00:c1::a9fe:202",
+		},
+		{
+			name:        "Address prefix",
+			prefix:      "fd00:00c2::",
+			ipv4IscsiIp: "169.254.2.2",
+			want:        "fd00:c2::a9fe:202",
+		},
+		{
+			name:        "Network prefix",
+			prefix:      "fd12:3456:789a::/96",
+			ipv4IscsiIp: "169.254.2.3",
+			want:        "fd12:3456:789a::a9fe:203",
+		},
+		{
+			name:        "Shorter network prefix",
+			prefix:      "fd00:c3::/64",
+			ipv4IscsiIp: "169.254.2.2",
+			want:        "fd00:c3::a9fe:202",
+		},
+		{
+			name:    "Network longer than /96",
+			prefix:  "fd00:c1::/112",
+			wantErr: true,
+		},
+		{
+			name:    "Host bits set",
+			prefix:  "fd00:c1::1",
+			wantErr: true,
+		},
+		{
+			name:    "Ipv4 prefix",
+			prefix:  "169.254.0.0/16",
+			wantErr: true,
+		},
+		{
+			name:    "Ipv4 mapped prefix",
+			prefix:  "::ffff:0:0",
+			wantErr: true,
+		},
+		{
+			name:    "Unparseable prefix",
+			prefix:  "fd00:zz::/96",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { _ = SetIscsiIpv6Prefix("") })
+			err := SetIscsiIpv6Prefix(tt.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetIscsiIpv6Prefix() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if got := GetIscsiIpv6Prefix(); got != IscsiIpv6Prefix {
+					t.Errorf("GetIscsiIpv6Prefix() = %v after rejected prefix, want %v", got, IscsiIpv6Prefix)
+				}
+				return
+			}
+			got, err := ConvertIscsiIpFromIpv4ToIpv6(tt.ipv4IscsiIp)
+			if err != nil {
+				t.Fatalf("ConvertIscsiIpFromIpv4ToIpv6() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertIscsiIpFromIpv4ToIpv6() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_BuildIpv6Portal(t *testing.T) {
+	tests := []struct {
+		name    string
+		ipv4Ip  string
+		port    int
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Valid iSCSI IPv4",
+			ipv4Ip: "169.254.2.2",
+			port:   3260,
+			want:   "[fd00:c1::a9fe:202]:3260",
+		},
+		{
+			name:   "Valid iSCSI IPv4 with non default port",
+			ipv4Ip: "169.254.5.4",
+			port:   3261,
+			want:   "[fd00:c1::a9fe:504]:3261",
+		},
+		{
+			name:    "Invalid iSCSI IPv4",
+			ipv4Ip:  "169.254.2",
+			port:    3260,
+			wantErr: true,
+		},
+		{
+			name:    "IPv6 input",
+			ipv4Ip:  "fd00:c1::a9fe:202",
+			port:    3260,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid port",
+			ipv4Ip:  "169.254.2.2",
+			port:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildIpv6Portal(tt.ipv4Ip, tt.port)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildIpv6Portal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("BuildIpv6Portal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_DiskByPathPatternForIscsi(t *testing.T) {
 
 	tests := []struct {
@@ -387,6 +811,7 @@ func Test_LoadNodeMetadataFromApiServer(t *testing.T) {
 				PreferredNodeIpFamily: Ipv4Stack,
 				Ipv4Enabled:           true,
 				Ipv6Enabled:           false,
+				IpFamilyDefaulted:     true,
 			},
 		},
 		{
@@ -402,6 +827,7 @@ func Test_LoadNodeMetadataFromApiServer(t *testing.T) {
 				PreferredNodeIpFamily: Ipv4Stack,
 				Ipv4Enabled:           true,
 				Ipv6Enabled:           false,
+				IpFamilyDefaulted:     true,
 			},
 			err: fmt.Errorf("Failed to get node information from kube api server, please check if kube api server is accessible."),
 		},
@@ -454,7 +880,8 @@ func Test_LoadNodeMetadataFromApiServer(t *testing.T) {
 
 			err := u.LoadNodeMetadataFromApiServer(ctx, k, tt.nodeName, nodeMetadata)
 			if (tt.want != nodeMetadata) && (tt.want.PreferredNodeIpFamily != nodeMetadata.PreferredNodeIpFamily ||
-				tt.want.Ipv6Enabled != nodeMetadata.Ipv6Enabled || tt.want.Ipv4Enabled != nodeMetadata.Ipv4Enabled) {
+				tt.want.Ipv6Enabled != nodeMetadata.Ipv6Enabled || tt.want.Ipv4Enabled != nodeMetadata.Ipv4Enabled ||
+				tt.want.IpFamilyDefaulted != nodeMetadata.IpFamilyDefaulted) {
 				t.Errorf("LoadNodeMetadataFromApiServer() = %v, want %v", nodeMetadata, tt.want)
 			}
 			if err != nil && !strings.EqualFold(tt.err.Error(), err.Error()) {
@@ -668,6 +1095,47 @@ func Test_IsValidIpFamilyPresentInClusterIpFamily(t *testing.T) {
 	}
 }
 
+func Test_DetectAddressFamilies(t *testing.T) {
+	tests := []struct {
+		name     string
+		addrs    []string
+		wantIpv4 bool
+		wantIpv6 bool
+	}{
+		{
+			name:     "Ipv4 only addresses",
+			addrs:    []string{"10.0.10.1", "10.0.10.2"},
+			wantIpv4: true,
+		},
+		{
+			name:     "Ipv6 only addresses",
+			addrs:    []string{"fd00:00c1::a9fe:202", "[fd00::1]"},
+			wantIpv6: true,
+		},
+		{
+			name:     "Mixed addresses",
+			addrs:    []string{"10.0.10.1", "fd00:00c1::a9fe:202"},
+			wantIpv4: true,
+			wantIpv6: true,
+		},
+		{
+			name:  "Invalid and empty addresses",
+			addrs: []string{"", "invalid"},
+		},
+		{
+			name: "No addresses",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotIpv4, gotIpv6 := DetectAddressFamilies(tt.addrs)
+			if gotIpv4 != tt.wantIpv4 || gotIpv6 != tt.wantIpv6 {
+				t.Errorf("DetectAddressFamilies() = (%v, %v), want (%v, %v)", gotIpv4, gotIpv6, tt.wantIpv4, tt.wantIpv6)
+			}
+		})
+	}
+}
+
 func Test_SubnetStack(t *testing.T) {
 	tests := []struct {
 		name                    string
@@ -845,3 +1313,3330 @@ func Test_LoadCSIConfigFromConfigMap(t *testing.T) {
 	}
 
 }
+
+func Test_ValidateIQN(t *testing.T) {
+	tests := []struct {
+		name    string
+		iqn     string
+		wantErr bool
+	}{
+		{
+			name:    "Valid OCI IQN",
+			iqn:     "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+			wantErr: false,
+		},
+		{
+			name:    "Empty IQN",
+			iqn:     "",
+			wantErr: true,
+		},
+		{
+			name:    "Missing iqn prefix",
+			iqn:     "2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid month in date",
+			iqn:     "iqn.2015-13.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+			wantErr: true,
+		},
+		{
+			name:    "Missing naming authority",
+			iqn:     "iqn.2015-12:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+			wantErr: true,
+		},
+		{
+			name:    "Missing unique name",
+			iqn:     "iqn.2015-12.com.oracleiaas",
+			wantErr: true,
+		},
+		{
+			name:    "Whitespace in unique name",
+			iqn:     "iqn.2015-12.com.oracleiaas:63a2e76c 5353",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIQN(tt.iqn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIQN() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ExtractISCSIInformation(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		wantErr    bool
+	}{
+		{
+			name: "Valid attributes",
+			attributes: map[string]string{
+				"iscci_iqn":  "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+				"iscsi_ip":   "169.254.2.2",
+				"iscsi_port": "3260",
+			},
+			wantErr: false,
+		},
+		{
+			name: "Malformed IQN",
+			attributes: map[string]string{
+				"iscci_iqn":  "not-an-iqn",
+				"iscsi_ip":   "169.254.2.2",
+				"iscsi_port": "3260",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ExtractISCSIInformation(tt.attributes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExtractISCSIInformation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_VolumeHandleHash(t *testing.T) {
+	hashPattern := regexp.MustCompile(`^[0-9a-f]{16}$`)
+	ids := []string{
+		"ocid1.volume.oc1.phx.abyhqljrxyz",
+		"ocid1.filesystem.oc1.phx.aaaa:10.0.10.1:/export",
+		"ocid1.filesystem.oc1.phx.aaaa:[fd00:c1::a9fe:202]:/export",
+		"",
+	}
+	seen := map[string]string{}
+	for _, id := range ids {
+		got := VolumeHandleHash(id)
+		if !hashPattern.MatchString(got) {
+			t.Errorf("VolumeHandleHash(%q) = %q, want 16 lowercase hex characters", id, got)
+		}
+		if again := VolumeHandleHash(id); again != got {
+			t.Errorf("VolumeHandleHash(%q) is not stable: %q != %q", id, got, again)
+		}
+		if other, ok := seen[got]; ok {
+			t.Errorf("VolumeHandleHash(%q) collides with %q", id, other)
+		}
+		seen[got] = id
+	}
+	if got, want := VolumeHandleHash("ocid1.volume.oc1.phx.abyhqljrxyz"), "b5c5ca409451a766"; got != want {
+		t.Errorf("VolumeHandleHash() = %q, want %q", got, want)
+	}
+}
+
+func Test_ValidateMountTargetInCIDRs(t *testing.T) {
+	tests := []struct {
+		name         string
+		ip           string
+		allowedCIDRs []string
+		wantErr      bool
+	}{
+		{
+			name:         "IPv4 mount target in range",
+			ip:           "10.0.10.5",
+			allowedCIDRs: []string{"192.168.0.0/16", "10.0.10.0/24"},
+		},
+		{
+			name:         "IPv4 mount target out of range",
+			ip:           "10.0.20.5",
+			allowedCIDRs: []string{"10.0.10.0/24"},
+			wantErr:      true,
+		},
+		{
+			name:         "IPv6 mount target in range",
+			ip:           "fd00:c1::a9fe:202",
+			allowedCIDRs: []string{"10.0.10.0/24", "fd00:c1::/64"},
+		},
+		{
+			name:         "Bracketed IPv6 mount target in range",
+			ip:           "[fd00:c1::a9fe:202]",
+			allowedCIDRs: []string{"fd00:c1::/64"},
+		},
+		{
+			name:         "IPv6 mount target out of range",
+			ip:           "fd00:c2::a9fe:202",
+			allowedCIDRs: []string{"fd00:c1::/64"},
+			wantErr:      true,
+		},
+		{
+			name:         "IPv4 mount target against IPv6 CIDR",
+			ip:           "10.0.10.5",
+			allowedCIDRs: []string{"fd00:c1::/64"},
+			wantErr:      true,
+		},
+		{
+			name:         "Invalid mount target IP",
+			ip:           "mount-target.example.com",
+			allowedCIDRs: []string{"10.0.10.0/24"},
+			wantErr:      true,
+		},
+		{
+			name:         "Invalid allowed CIDR",
+			ip:           "10.0.10.5",
+			allowedCIDRs: []string{"10.0.10.0/33"},
+			wantErr:      true,
+		},
+		{
+			name:    "No allowed CIDRs",
+			ip:      "10.0.10.5",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMountTargetInCIDRs(tt.ip, tt.allowedCIDRs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMountTargetInCIDRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ValidateExportPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		export  string
+		wantErr bool
+	}{
+		{
+			name:   "Export at the file system root",
+			export: "/",
+		},
+		{
+			name:   "Single level export",
+			export: "/FileSystem-Test",
+		},
+		{
+			name:   "Nested export",
+			export: "/exports/team-a/data",
+		},
+		{
+			name:    "Empty export",
+			export:  "",
+			wantErr: true,
+		},
+		{
+			name:    "Export without leading slash",
+			export:  "FileSystem-Test",
+			wantErr: true,
+		},
+		{
+			name:    "Export with parent segment",
+			export:  "/exports/../data",
+			wantErr: true,
+		},
+		{
+			name:    "Export with current segment",
+			export:  "/exports/./data",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExportPath(tt.export)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateExportPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_MountTargetAvailabilityDomain(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		want       string
+		wantOk     bool
+	}{
+		{
+			name:       "Availability domain present",
+			attributes: map[string]string{FssMountTargetAvailabilityDomainKey: "Uocm:PHX-AD-1"},
+			want:       "Uocm:PHX-AD-1",
+			wantOk:     true,
+		},
+		{
+			name:       "Availability domain absent",
+			attributes: map[string]string{FssMountTargetIpKey: "10.0.10.1"},
+		},
+		{
+			name:       "Availability domain empty",
+			attributes: map[string]string{FssMountTargetAvailabilityDomainKey: " "},
+		},
+		{
+			name: "Nil attributes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := MountTargetAvailabilityDomain(tt.attributes)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("MountTargetAvailabilityDomain() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_ExtractFSSInformation(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		want       *FSSVolumeHandler
+		wantErr    bool
+	}{
+		{
+			name: "Complete attributes",
+			attributes: map[string]string{
+				"filesystemOcid":   "ocid1.filesystem.oc1.phx.aaaa",
+				"mountTargetIp":    "10.0.10.1",
+				"exportPath":       "/export",
+				"encryptInTransit": "false",
+			},
+			want: &FSSVolumeHandler{"ocid1.filesystem.oc1.phx.aaaa", "10.0.10.1", "/export"},
+		},
+		{
+			name: "Non filesystem OCID",
+			attributes: map[string]string{
+				"filesystemOcid": "ocid1.volume.oc1.phx.aaaa",
+				"mountTargetIp":  "10.0.10.1",
+				"exportPath":     "/export",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Bracketed IPv6 mount target",
+			attributes: map[string]string{
+				"filesystemOcid": "ocid1.filesystem.oc1.phx.aaaa",
+				"mountTargetIp":  "[fd00:00c1::a9fe:202]",
+				"exportPath":     "/export",
+			},
+			want: &FSSVolumeHandler{"ocid1.filesystem.oc1.phx.aaaa", "[fd00:00c1::a9fe:202]", "/export"},
+		},
+		{
+			name: "Missing filesystem OCID",
+			attributes: map[string]string{
+				"mountTargetIp": "10.0.10.1",
+				"exportPath":    "/export",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Missing mount target IP",
+			attributes: map[string]string{
+				"filesystemOcid": "ocid1.filesystem.oc1.phx.aaaa",
+				"exportPath":     "/export",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid mount target IP",
+			attributes: map[string]string{
+				"filesystemOcid": "ocid1.filesystem.oc1.phx.aaaa",
+				"mountTargetIp":  "10.0.10.1:2049",
+				"exportPath":     "/export",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Missing export path",
+			attributes: map[string]string{
+				"filesystemOcid": "ocid1.filesystem.oc1.phx.aaaa",
+				"mountTargetIp":  "10.0.10.1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Relative export path",
+			attributes: map[string]string{
+				"filesystemOcid": "ocid1.filesystem.oc1.phx.aaaa",
+				"mountTargetIp":  "10.0.10.1",
+				"exportPath":     "export",
+			},
+			wantErr: true,
+		},
+		{
+			name:       "Empty attributes",
+			attributes: map[string]string{},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractFSSInformation(tt.attributes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractFSSInformation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractFSSInformation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_JoinCSIPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []string
+		want  string
+	}{
+		{
+			name:  "Join staging path with raw block file",
+			parts: []string{"/var/lib/kubelet/plugins/kubernetes.io/csi/pv/pvc-1/globalmount", RawBlockStagingFile},
+			want:  "/var/lib/kubelet/plugins/kubernetes.io/csi/pv/pvc-1/globalmount/mountfile",
+		},
+		{
+			name:  "Clean duplicate and trailing separators",
+			parts: []string{"/var/lib//kubelet/", "plugins/"},
+			want:  "/var/lib/kubelet/plugins",
+		},
+		{
+			name:  "Backslashes are converted to forward slashes",
+			parts: []string{`\var\lib\kubelet`, "plugins"},
+			want:  "/var/lib/kubelet/plugins",
+		},
+		{
+			name:  "Relative path",
+			parts: []string{"staging", "..", "target"},
+			want:  "target",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JoinCSIPath(tt.parts...); got != tt.want {
+				t.Errorf("JoinCSIPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GetPathForBlock(t *testing.T) {
+	got := GetPathForBlock("/staging/path/")
+	if got != "/staging/path/mountfile" {
+		t.Errorf("GetPathForBlock() = %v, want %v", got, "/staging/path/mountfile")
+	}
+}
+
+func Test_StagingPathForVolume(t *testing.T) {
+	base := "/var/lib/kubelet/plugins/blockvolume.csi.oraclecloud.com/staging"
+	volumeIDs := []string{
+		"ocid1.volume.oc1.phx.abyhqljrgvttnlx73nmrwfaux7kcvzfs3s66izvxf2h4lgvyndsdsnoiwr5q",
+		"ocid1.volume.oc1.phx.abyhqljrgvttnlx73nmrwfaux7kcvzfs3s66izvxf2h4lgvyndsdsnoiwr5r",
+		"ocid1.filesystem.oc1.phx.aaaaaaaaaaaaaaaa:10.0.10.1:/export/../path",
+	}
+
+	seen := map[string]string{}
+	for _, volumeID := range volumeIDs {
+		got := StagingPathForVolume(base, volumeID)
+		if got != StagingPathForVolume(base, volumeID) {
+			t.Errorf("StagingPathForVolume(%q) is not stable", volumeID)
+		}
+		if want := base + "/" + VolumeHandleHash(volumeID); got != want {
+			t.Errorf("StagingPathForVolume(%q) = %v, want %v", volumeID, got, want)
+		}
+		if other, ok := seen[got]; ok {
+			t.Errorf("StagingPathForVolume(%q) = %v collides with volume %q", volumeID, got, other)
+		}
+		seen[got] = volumeID
+	}
+
+	if got, want := StagingPathForVolume(base+"/", volumeIDs[0]), StagingPathForVolume(base, volumeIDs[0]); got != want {
+		t.Errorf("StagingPathForVolume() with trailing separator = %v, want %v", got, want)
+	}
+}
+
+func Test_JitteredBackoff(t *testing.T) {
+	base := 100 * time.Millisecond
+	max := 5 * time.Second
+
+	tests := []struct {
+		name        string
+		attempt     int
+		wantCeiling time.Duration
+	}{
+		{
+			name:        "First attempt",
+			attempt:     0,
+			wantCeiling: base,
+		},
+		{
+			name:        "Third attempt",
+			attempt:     2,
+			wantCeiling: 400 * time.Millisecond,
+		},
+		{
+			name:        "Capped at max",
+			attempt:     10,
+			wantCeiling: max,
+		},
+		{
+			name:        "Large attempt does not overflow",
+			attempt:     1000,
+			wantCeiling: max,
+		},
+		{
+			name:        "Negative attempt",
+			attempt:     -1,
+			wantCeiling: base,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoffCeiling(tt.attempt, base, max); got != tt.wantCeiling {
+				t.Errorf("backoffCeiling() = %v, want %v", got, tt.wantCeiling)
+			}
+			for i := 0; i < 100; i++ {
+				if got := JitteredBackoff(tt.attempt, base, max); got < 0 || got > tt.wantCeiling {
+					t.Fatalf("JitteredBackoff() = %v, want within [0, %v]", got, tt.wantCeiling)
+				}
+			}
+		})
+	}
+
+	t.Run("Ceiling grows monotonically until the cap", func(t *testing.T) {
+		previous := time.Duration(0)
+		for attempt := 0; attempt < 10; attempt++ {
+			ceiling := backoffCeiling(attempt, base, max)
+			if ceiling < max && ceiling <= previous {
+				t.Errorf("backoffCeiling(%d) = %v, want more than %v", attempt, ceiling, previous)
+			}
+			if ceiling < previous {
+				t.Errorf("backoffCeiling(%d) = %v, must not shrink from %v", attempt, ceiling, previous)
+			}
+			previous = ceiling
+		}
+	})
+
+	t.Run("Zero base", func(t *testing.T) {
+		if got := JitteredBackoff(3, 0, max); got != 0 {
+			t.Errorf("JitteredBackoff() = %v, want 0", got)
+		}
+	})
+}
+
+func Test_RecommendedNFSTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		sameAD      bool
+		wantTimeo   int
+		wantRetrans int
+	}{
+		{
+			name:        "Mount target in the same AD",
+			sameAD:      true,
+			wantTimeo:   600,
+			wantRetrans: 2,
+		},
+		{
+			name:        "Mount target in another AD",
+			sameAD:      false,
+			wantTimeo:   900,
+			wantRetrans: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeo, retrans := RecommendedNFSTimeouts(tt.sameAD)
+			if timeo != tt.wantTimeo || retrans != tt.wantRetrans {
+				t.Errorf("RecommendedNFSTimeouts() = (%v, %v), want (%v, %v)", timeo, retrans, tt.wantTimeo, tt.wantRetrans)
+			}
+		})
+	}
+}
+
+func Test_ToOCIAlignedBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		bytes int64
+		want  int64
+	}{
+		{
+			name:  "Aligned size is unchanged",
+			bytes: 50 * client.GiB,
+			want:  50 * client.GiB,
+		},
+		{
+			name:  "One byte over rounds up",
+			bytes: 50*client.GiB + 1,
+			want:  51 * client.GiB,
+		},
+		{
+			name:  "MiB size rounds up",
+			bytes: 50000 * client.MiB,
+			want:  49 * client.GiB,
+		},
+		{
+			name:  "Sub GiB size rounds up",
+			bytes: 1,
+			want:  client.GiB,
+		},
+		{
+			name:  "Zero size",
+			bytes: 0,
+			want:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToOCIAlignedBytes(tt.bytes)
+			if got != tt.want {
+				t.Errorf("ToOCIAlignedBytes() = %v, want %v", got, tt.want)
+			}
+			if got > 0 {
+				if err := ValidateOCIAlignedBytes(got); err != nil {
+					t.Errorf("ValidateOCIAlignedBytes() error = %v for aligned size %v", err, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_ValidateOCIAlignedBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		bytes   int64
+		wantErr bool
+	}{
+		{
+			name:  "Aligned size",
+			bytes: 50 * client.GiB,
+		},
+		{
+			name:    "Misaligned size",
+			bytes:   50*client.GiB + client.MiB,
+			wantErr: true,
+		},
+		{
+			name:    "Zero size",
+			bytes:   0,
+			wantErr: true,
+		},
+		{
+			name:    "Negative size",
+			bytes:   -client.GiB,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateOCIAlignedBytes(tt.bytes); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOCIAlignedBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_enforceFipsForEncryptedVolume(t *testing.T) {
+	tests := []struct {
+		name          string
+		encrypted     bool
+		isFipsEnabled func() (string, error)
+		wantCode      codes.Code
+	}{
+		{
+			name:          "Encrypted volume on FIPS node",
+			encrypted:     true,
+			isFipsEnabled: func() (string, error) { return "1\n", nil },
+			wantCode:      codes.OK,
+		},
+		{
+			name:          "Encrypted volume on non FIPS node",
+			encrypted:     true,
+			isFipsEnabled: func() (string, error) { return "0\n", nil },
+			wantCode:      codes.FailedPrecondition,
+		},
+		{
+			name:          "Encrypted volume when FIPS check fails",
+			encrypted:     true,
+			isFipsEnabled: func() (string, error) { return "", fmt.Errorf("no such file") },
+			wantCode:      codes.Internal,
+		},
+		{
+			name:      "Unencrypted volume skips the FIPS check",
+			encrypted: false,
+			isFipsEnabled: func() (string, error) {
+				t.Errorf("FIPS must not be checked for unencrypted volumes")
+				return "0", nil
+			},
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := enforceFipsForEncryptedVolume(tt.encrypted, tt.isFipsEnabled)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("enforceFipsForEncryptedVolume() code = %v, want %v, error = %v", got, tt.wantCode, err)
+			}
+		})
+	}
+}
+
+func Test_DefaultVolumeSize(t *testing.T) {
+	if got := DefaultVolumeSizeBytes(); got != MinimumVolumeSizeInBytes {
+		t.Errorf("DefaultVolumeSizeBytes() = %v, want %v", got, MinimumVolumeSizeInBytes)
+	}
+	if got := DefaultVolumeSizeGiB(); got != RoundUpMinSize() {
+		t.Errorf("DefaultVolumeSizeGiB() = %v, want %v", got, RoundUpMinSize())
+	}
+	if got := DefaultVolumeSizeGiB(); got != 50 {
+		t.Errorf("DefaultVolumeSizeGiB() = %v, want %v", got, 50)
+	}
+}
+
+func Test_SetMaximumVolumeSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		bytes   int64
+		want    int64
+		wantErr bool
+	}{
+		{
+			name:    "Raise maximum volume size",
+			bytes:   64 * client.TiB,
+			want:    64 * client.TiB,
+			wantErr: false,
+		},
+		{
+			name:    "Maximum equal to absolute ceiling",
+			bytes:   AbsoluteMaximumVolumeSizeInBytes,
+			want:    AbsoluteMaximumVolumeSizeInBytes,
+			wantErr: false,
+		},
+		{
+			name:    "Maximum above absolute ceiling is rejected",
+			bytes:   AbsoluteMaximumVolumeSizeInBytes + 1,
+			want:    MaximumVolumeSizeInBytes,
+			wantErr: true,
+		},
+		{
+			name:    "Maximum below minimum volume size is rejected",
+			bytes:   1 * client.GiB,
+			want:    MaximumVolumeSizeInBytes,
+			wantErr: true,
+		},
+		{
+			name:    "Negative maximum is rejected",
+			bytes:   -1,
+			want:    MaximumVolumeSizeInBytes,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer SetMaximumVolumeSize(MaximumVolumeSizeInBytes)
+			err := SetMaximumVolumeSize(tt.bytes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetMaximumVolumeSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := GetMaximumVolumeSize(); got != tt.want {
+				t.Errorf("GetMaximumVolumeSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidateNotShrinking(t *testing.T) {
+	tests := []struct {
+		name           string
+		currentBytes   int64
+		requestedBytes int64
+		wantErr        bool
+	}{
+		{
+			name:           "Grow volume",
+			currentBytes:   50 * client.GiB,
+			requestedBytes: 100 * client.GiB,
+			wantErr:        false,
+		},
+		{
+			name:           "Same size",
+			currentBytes:   50 * client.GiB,
+			requestedBytes: 50 * client.GiB,
+			wantErr:        false,
+		},
+		{
+			name:           "Shrink volume",
+			currentBytes:   100 * client.GiB,
+			requestedBytes: 50 * client.GiB,
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNotShrinking(tt.currentBytes, tt.requestedBytes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateNotShrinking() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ExtractBlockVolumePerformanceLevelFromParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		want    int64
+		wantErr bool
+	}{
+		{
+			name:   "Exact key",
+			params: map[string]string{"vpusPerGB": "20"},
+			want:   20,
+		},
+		{
+			name:   "Mis-cased key",
+			params: map[string]string{"VpusPerGB": "0"},
+			want:   0,
+		},
+		{
+			name:   "Lower cased key",
+			params: map[string]string{"vpuspergb": "30"},
+			want:   30,
+		},
+		{
+			name:   "Absent key defaults to balanced",
+			params: map[string]string{"attachment-type": "iscsi"},
+			want:   BalancedPerformanceOption,
+		},
+		{
+			name:   "Nil params defaults to balanced",
+			params: nil,
+			want:   BalancedPerformanceOption,
+		},
+		{
+			name:   "Duplicate keys with the same value",
+			params: map[string]string{"vpusPerGB": "20", "vpuspergb": "20"},
+			want:   20,
+		},
+		{
+			name:    "Duplicate keys with conflicting values",
+			params:  map[string]string{"vpusPerGB": "20", "VPUSPERGB": "10"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid value",
+			params:  map[string]string{"VpusPerGB": "abc"},
+			wantErr: true,
+		},
+		{
+			name:   "Near-miss key is ignored",
+			params: map[string]string{"vpus-per-gb": "20"},
+			want:   BalancedPerformanceOption,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractBlockVolumePerformanceLevelFromParams(zap.S(), tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExtractBlockVolumePerformanceLevelFromParams() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ExtractBlockVolumePerformanceLevelFromParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_nearMissPerformanceKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   []string
+	}{
+		{
+			name:   "Exact and mis-cased keys are not near misses",
+			params: map[string]string{"vpusPerGB": "20", "VPUSPERGB": "20"},
+		},
+		{
+			name:   "Separated key",
+			params: map[string]string{"vpus-per-gb": "20", "vpus_per_gb": "20"},
+			want:   []string{"vpus-per-gb", "vpus_per_gb"},
+		},
+		{
+			name:   "Misspelled keys",
+			params: map[string]string{"vpuPerGB": "20", "vpusPerGiB": "20", "vpusPrGB": "20"},
+			want:   []string{"vpuPerGB", "vpusPerGiB", "vpusPrGB"},
+		},
+		{
+			name:   "Unrelated keys",
+			params: map[string]string{"attachment-type": "iscsi", "vpus": "20", "fsType": "xfs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nearMissPerformanceKeys(tt.params); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nearMissPerformanceKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidateDriverSocketConfig(t *testing.T) {
+	tests := []struct {
+		name             string
+		driverName       string
+		endpoint         string
+		registrationPath string
+		wantErr          bool
+	}{
+		{
+			name:             "Block volume driver with consistent paths",
+			driverName:       "blockvolume.csi.oraclecloud.com",
+			endpoint:         "unix:///csi/csi.sock",
+			registrationPath: "/var/lib/kubelet/plugins/blockvolume.csi.oraclecloud.com/csi.sock",
+		},
+		{
+			name:             "Block volume driver with mismatched socket name",
+			driverName:       "blockvolume.csi.oraclecloud.com",
+			endpoint:         "unix:///csi/csi.sock",
+			registrationPath: "/var/lib/kubelet/plugins/blockvolume.csi.oraclecloud.com/bv.sock",
+			wantErr:          true,
+		},
+		{
+			name:             "FSS driver with consistent paths",
+			driverName:       "fss.csi.oraclecloud.com",
+			endpoint:         "unix:///fss/csi.sock",
+			registrationPath: "/var/lib/kubelet/plugins/fss.csi.oraclecloud.com/csi.sock",
+		},
+		{
+			name:             "FSS driver registered under the block volume driver directory",
+			driverName:       "fss.csi.oraclecloud.com",
+			endpoint:         "unix:///fss/csi.sock",
+			registrationPath: "/var/lib/kubelet/plugins/blockvolume.csi.oraclecloud.com/csi.sock",
+			wantErr:          true,
+		},
+		{
+			name:             "Lustre driver with consistent paths",
+			driverName:       "lustre.csi.oraclecloud.com",
+			endpoint:         "unix:///lustre/csi.sock",
+			registrationPath: "/var/lib/kubelet/plugins/lustre.csi.oraclecloud.com/csi.sock",
+		},
+		{
+			name:             "Lustre driver with relative registration path",
+			driverName:       "lustre.csi.oraclecloud.com",
+			endpoint:         "unix:///lustre/csi.sock",
+			registrationPath: "lustre.csi.oraclecloud.com/csi.sock",
+			wantErr:          true,
+		},
+		{
+			name:       "Endpoint with host form and no registration path",
+			driverName: "blockvolume.csi.oraclecloud.com",
+			endpoint:   "unix://tmp/csi.sock",
+		},
+		{
+			name:       "Non unix endpoint",
+			driverName: "blockvolume.csi.oraclecloud.com",
+			endpoint:   "tcp://127.0.0.1:10000",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDriverSocketConfig(tt.driverName, tt.endpoint, tt.registrationPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDriverSocketConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ParseEndpoint(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "csi.sock"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		endpoint string
+		wantAddr string
+		wantErr  bool
+	}{
+		{
+			name:     "Valid socket path",
+			endpoint: "unix://" + filepath.Join(dir, "bv.sock"),
+			wantAddr: filepath.Join(dir, "bv.sock"),
+		},
+		{
+			name:     "Socket path is an existing directory",
+			endpoint: "unix://" + filepath.Join(dir, "csi.sock"),
+			wantErr:  true,
+		},
+		{
+			name:     "Endpoint names the directory itself",
+			endpoint: "unix://" + dir,
+			wantErr:  true,
+		},
+		{
+			name:     "Parent directory does not exist",
+			endpoint: "unix://" + filepath.Join(dir, "missing", "csi.sock"),
+			wantErr:  true,
+		},
+		{
+			name:     "Non unix endpoint",
+			endpoint: "tcp://127.0.0.1:10000",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, addr, err := ParseEndpoint(tt.endpoint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if scheme != "unix" || addr != tt.wantAddr {
+				t.Errorf("ParseEndpoint() = (%s, %s), want (unix, %s)", scheme, addr, tt.wantAddr)
+			}
+		})
+	}
+}
+
+func Test_RemoveStaleSocket(t *testing.T) {
+	dir := t.TempDir()
+	staleSocket := filepath.Join(dir, "stale.sock")
+	if err := os.WriteFile(staleSocket, nil, 0600); err != nil {
+		t.Fatalf("failed to create stale socket: %v", err)
+	}
+	// A file named like a tcp host:port must never be touched.
+	hostPortFile := filepath.Join(dir, "127.0.0.1:10000")
+	if err := os.WriteFile(hostPortFile, nil, 0600); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		endpoint    string
+		workDir     string
+		removedPath string
+		keptPath    string
+		wantErr     bool
+	}{
+		{
+			name:        "Stale unix socket is removed",
+			endpoint:    "unix://" + staleSocket,
+			removedPath: staleSocket,
+		},
+		{
+			name:     "Missing unix socket is ignored",
+			endpoint: "unix://" + filepath.Join(dir, "missing.sock"),
+		},
+		{
+			name:     "Unix socket in a missing directory",
+			endpoint: "unix://" + filepath.Join(dir, "missing", "csi.sock"),
+			wantErr:  true,
+		},
+		{
+			name:     "Tcp endpoint is a no-op",
+			endpoint: "tcp://127.0.0.1:10000",
+			workDir:  dir,
+			keptPath: hostPortFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.workDir != "" {
+				t.Chdir(tt.workDir)
+			}
+			err := RemoveStaleSocket(tt.endpoint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RemoveStaleSocket() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.removedPath != "" {
+				if _, err := os.Stat(tt.removedPath); !os.IsNotExist(err) {
+					t.Errorf("RemoveStaleSocket() did not remove %s", tt.removedPath)
+				}
+			}
+			if tt.keptPath != "" {
+				if _, err := os.Stat(tt.keptPath); err != nil {
+					t.Errorf("RemoveStaleSocket() removed %s: %v", tt.keptPath, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_NodeStack(t *testing.T) {
+	tests := []struct {
+		name                  string
+		nodeMetadata          *NodeMetadata
+		isIpv4SingleStackNode bool
+		isIpv6SingleStackNode bool
+		isDualStackNode       bool
+	}{
+		{
+			name:                  "IPv4 single stack node",
+			nodeMetadata:          &NodeMetadata{PreferredNodeIpFamily: Ipv4Stack, Ipv4Enabled: true},
+			isIpv4SingleStackNode: true,
+		},
+		{
+			name:                  "IPv6 single stack node",
+			nodeMetadata:          &NodeMetadata{PreferredNodeIpFamily: Ipv6Stack, Ipv6Enabled: true},
+			isIpv6SingleStackNode: true,
+		},
+		{
+			name:            "Dual stack node",
+			nodeMetadata:    &NodeMetadata{PreferredNodeIpFamily: Ipv4Stack, Ipv4Enabled: true, Ipv6Enabled: true},
+			isDualStackNode: true,
+		},
+		{
+			name:         "Nil node metadata",
+			nodeMetadata: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsIpv4SingleStackNode(tt.nodeMetadata); got != tt.isIpv4SingleStackNode {
+				t.Errorf("IsIpv4SingleStackNode() = %v, want %v", got, tt.isIpv4SingleStackNode)
+			}
+			if got := IsIpv6SingleStackNode(tt.nodeMetadata); got != tt.isIpv6SingleStackNode {
+				t.Errorf("IsIpv6SingleStackNode() = %v, want %v", got, tt.isIpv6SingleStackNode)
+			}
+			if got := IsDualStackNode(tt.nodeMetadata); got != tt.isDualStackNode {
+				t.Errorf("IsDualStackNode() = %v, want %v", got, tt.isDualStackNode)
+			}
+		})
+	}
+}
+
+func Test_NodeMetadataIpFamilyString(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodeMetadata *NodeMetadata
+		want         string
+	}{
+		{
+			name:         "IPv4 single stack node",
+			nodeMetadata: &NodeMetadata{PreferredNodeIpFamily: Ipv4Stack, Ipv4Enabled: true},
+			want:         "preferred=IPv4,v4=true,v6=false",
+		},
+		{
+			name:         "IPv6 single stack node",
+			nodeMetadata: &NodeMetadata{PreferredNodeIpFamily: Ipv6Stack, Ipv6Enabled: true},
+			want:         "preferred=IPv6,v4=false,v6=true",
+		},
+		{
+			name:         "Dual stack node",
+			nodeMetadata: &NodeMetadata{PreferredNodeIpFamily: Ipv6Stack, Ipv4Enabled: true, Ipv6Enabled: true},
+			want:         "preferred=IPv6,v4=true,v6=true",
+		},
+		{
+			name:         "Nil node metadata",
+			nodeMetadata: nil,
+			want:         "<nil>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.nodeMetadata.IpFamilyString(); got != tt.want {
+				t.Errorf("NodeMetadata.IpFamilyString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GetNodeAvailabilityDomainLabel(t *testing.T) {
+	tests := []struct {
+		name   string
+		node   *v1.Node
+		wantAD string
+		wantOk bool
+	}{
+		{
+			name: "Node labeled with topology zone",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.LabelTopologyZone:      "PHX-AD-1",
+					v1.LabelZoneFailureDomain: "PHX-AD-2",
+				},
+			}},
+			wantAD: "PHX-AD-1",
+			wantOk: true,
+		},
+		{
+			name: "Node labeled with deprecated failure domain",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.LabelZoneFailureDomain: "PHX-AD-2"},
+			}},
+			wantAD: "PHX-AD-2",
+			wantOk: true,
+		},
+		{
+			name: "Node without availability domain labels",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{"foo": "bar"},
+			}},
+		},
+		{
+			name: "Nil node",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAD, gotOk := GetNodeAvailabilityDomainLabel(tt.node)
+			if gotAD != tt.wantAD || gotOk != tt.wantOk {
+				t.Errorf("GetNodeAvailabilityDomainLabel() = (%q, %v), want (%q, %v)", gotAD, gotOk, tt.wantAD, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_GetNodeRegionLabel(t *testing.T) {
+	tests := []struct {
+		name       string
+		node       *v1.Node
+		wantRegion string
+		wantOk     bool
+	}{
+		{
+			name: "Node labeled with topology region",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.LabelTopologyRegion: "us-ashburn-1"},
+			}},
+			wantRegion: "us-ashburn-1",
+			wantOk:     true,
+		},
+		{
+			name: "Node with empty topology region label",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.LabelTopologyRegion: ""},
+			}},
+		},
+		{
+			name: "Node without topology region label",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.LabelTopologyZone: "PHX-AD-1"},
+			}},
+		},
+		{
+			name: "Nil node",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRegion, gotOk := GetNodeRegionLabel(tt.node)
+			if gotRegion != tt.wantRegion || gotOk != tt.wantOk {
+				t.Errorf("GetNodeRegionLabel() = (%q, %v), want (%q, %v)", gotRegion, gotOk, tt.wantRegion, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_NodeTopologySegments(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    *v1.Node
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "Node labeled with zone and region",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.LabelTopologyZone:   "PHX-AD-1",
+					v1.LabelTopologyRegion: "us-phoenix-1",
+				},
+			}},
+			want: map[string]string{
+				v1.LabelZoneFailureDomain: "PHX-AD-1",
+				v1.LabelTopologyZone:      "PHX-AD-1",
+				v1.LabelTopologyRegion:    "us-phoenix-1",
+			},
+		},
+		{
+			name: "Node labeled with zone only",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.LabelTopologyZone: "PHX-AD-1"},
+			}},
+			want: map[string]string{
+				v1.LabelZoneFailureDomain: "PHX-AD-1",
+				v1.LabelTopologyZone:      "PHX-AD-1",
+			},
+		},
+		{
+			name: "Node labeled with region only",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.LabelTopologyRegion: "us-phoenix-1"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeMetadata := &NodeMetadata{}
+			loadNodeMetadataFromLabels(tt.node, nodeMetadata)
+			got, err := NodeTopologySegments(nodeMetadata)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NodeTopologySegments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NodeTopologySegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidateAccessTypeParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		isBlock bool
+		fsType  string
+		wantErr bool
+	}{
+		{
+			name:    "Raw block with fsType",
+			isBlock: true,
+			fsType:  "ext4",
+			wantErr: true,
+		},
+		{
+			name:    "Raw block without fsType",
+			isBlock: true,
+			fsType:  "",
+			wantErr: false,
+		},
+		{
+			name:    "Mount with fsType",
+			isBlock: false,
+			fsType:  "xfs",
+			wantErr: false,
+		},
+		{
+			name:    "Mount without fsType",
+			isBlock: false,
+			fsType:  "",
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAccessTypeParams(tt.isBlock, tt.fsType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAccessTypeParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ConvertIscsiIpsToIpv6(t *testing.T) {
+	tests := []struct {
+		name    string
+		ipv4Ips []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "All valid IPv4 portals",
+			ipv4Ips: []string{"169.254.2.2", "169.254.5.4"},
+			want:    []string{"fd00:c1::a9fe:202", "fd00:c1::a9fe:504"},
+		},
+		{
+			name:    "Empty portal list",
+			ipv4Ips: []string{},
+			want:    []string{},
+		},
+		{
+			name:    "Mix of valid and invalid IPv4 portals",
+			ipv4Ips: []string{"169.254.2.2", "169.254.2", ""},
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate IPv4 portals collide",
+			ipv4Ips: []string{"169.254.2.2", "169.254.5.4", "169.254.2.2"},
+			wantErr: true,
+		},
+		{
+			name:    "IPv4-mapped IPv6 portal collides with its IPv4 form",
+			ipv4Ips: []string{"169.254.2.2", "::ffff:169.254.2.2"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertIscsiIpsToIpv6(tt.ipv4Ips)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ConvertIscsiIpsToIpv6() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), "169.254.2") {
+					t.Errorf("ConvertIscsiIpsToIpv6() error = %v, want it to name the invalid IP", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConvertIscsiIpsToIpv6() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_VolumeLogFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		volumeID    string
+		nodeID      string
+		stagingPath string
+		want        []interface{}
+	}{
+		{
+			name:        "All fields set",
+			volumeID:    "ocid1.volume.oc1.phx.aaaa",
+			nodeID:      "ocid1.instance.oc1.phx.bbbb",
+			stagingPath: "/var/lib/kubelet/plugins/kubernetes.io/csi/pv/pvc-1/globalmount",
+			want: []interface{}{
+				"volumeID", "ocid1.volume.oc1.phx.aaaa",
+				"nodeId", "ocid1.instance.oc1.phx.bbbb",
+				"stagingPath", "/var/lib/kubelet/plugins/kubernetes.io/csi/pv/pvc-1/globalmount",
+			},
+		},
+		{
+			name:     "Empty node and staging path are omitted",
+			volumeID: "ocid1.volume.oc1.phx.aaaa",
+			want:     []interface{}{"volumeID", "ocid1.volume.oc1.phx.aaaa"},
+		},
+		{
+			name: "No fields set",
+			want: []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VolumeLogFields(tt.volumeID, tt.nodeID, tt.stagingPath); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VolumeLogFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_DevicePathForDisk(t *testing.T) {
+	iqn := "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca"
+	tests := []struct {
+		name    string
+		disk    *disk.Disk
+		lun     int
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "IPv4 portal",
+			disk: &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			lun:  1,
+			want: "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-1",
+		},
+		{
+			name: "IPv6 portal",
+			disk: &disk.Disk{IQN: iqn, IscsiIp: "fd00:c1::a9fe:202", Port: 3260},
+			lun:  2,
+			want: "/dev/disk/by-path/ip-[fd00:c1::a9fe:202]:3260-iscsi-" + iqn + "-lun-2",
+		},
+		{
+			name: "Bracketed IPv6 portal",
+			disk: &disk.Disk{IQN: iqn, IscsiIp: "[fd00:c1::a9fe:202]", Port: 3260},
+			lun:  1,
+			want: "/dev/disk/by-path/ip-[fd00:c1::a9fe:202]:3260-iscsi-" + iqn + "-lun-1",
+		},
+		{
+			name:    "Nil disk",
+			disk:    nil,
+			lun:     1,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid iSCSI IP",
+			disk:    &disk.Disk{IQN: iqn, IscsiIp: "169.254.2", Port: 3260},
+			lun:     1,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid port",
+			disk:    &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 0},
+			lun:     1,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid IQN",
+			disk:    &disk.Disk{IQN: "not-an-iqn", IscsiIp: "169.254.2.2", Port: 3260},
+			lun:     1,
+			wantErr: true,
+		},
+		{
+			name:    "Negative lun",
+			disk:    &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			lun:     -1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DevicePathForDisk(tt.disk, tt.lun)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DevicePathForDisk() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("DevicePathForDisk() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_resolvePersistentDevicePath(t *testing.T) {
+	if _, err := os.Stat("/dev/null"); err != nil {
+		t.Skip("/dev/null is not available")
+	}
+	iqn := "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca"
+	rootDir := t.TempDir()
+	byPathDir := filepath.Join(rootDir, disk.DISK_BY_PATH_FOLDER)
+	if err := os.MkdirAll(byPathDir, 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", byPathDir, err)
+	}
+	regularFile := filepath.Join(rootDir, "sdc")
+	if err := os.WriteFile(regularFile, nil, 0644); err != nil {
+		t.Fatalf("failed to create %s: %v", regularFile, err)
+	}
+	links := map[string]string{
+		"ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-1": "/dev/null",
+		"ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-2": regularFile,
+		"ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-3": filepath.Join(rootDir, "sdd"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(byPathDir, name)); err != nil {
+			t.Fatalf("failed to create link %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		disk    *disk.Disk
+		lun     int
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Link resolves to a device",
+			disk: &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			lun:  1,
+			want: "/dev/disk/by-path/ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-1",
+		},
+		{
+			name:    "Link resolves to a regular file",
+			disk:    &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			lun:     2,
+			wantErr: true,
+		},
+		{
+			name:    "Dangling link",
+			disk:    &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			lun:     3,
+			wantErr: true,
+		},
+		{
+			name:    "Missing link",
+			disk:    &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			lun:     4,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid disk",
+			disk:    &disk.Disk{IQN: "not-an-iqn", IscsiIp: "169.254.2.2", Port: 3260},
+			lun:     1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePersistentDevicePath(rootDir, tt.disk, tt.lun)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolvePersistentDevicePath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolvePersistentDevicePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseISCSIByPathLink(t *testing.T) {
+	iqn := "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca"
+	tests := []struct {
+		name     string
+		link     string
+		want     iscsiByPathLink
+		wantName string
+		wantOk   bool
+	}{
+		{
+			name:     "IPv4 portal",
+			link:     "ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-1",
+			want:     iscsiByPathLink{ip: net.ParseIP("169.254.2.2"), port: 3260, iqn: iqn, lun: 1},
+			wantName: "ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-1",
+			wantOk:   true,
+		},
+		{
+			name:     "Unbracketed IPv6 portal",
+			link:     "ip-fd00:00c1::a9fe:202:3260-iscsi-" + iqn + "-lun-2",
+			want:     iscsiByPathLink{ip: net.ParseIP("fd00:c1::a9fe:202"), port: 3260, iqn: iqn, lun: 2},
+			wantName: "ip-[fd00:c1::a9fe:202]:3260-iscsi-" + iqn + "-lun-2",
+			wantOk:   true,
+		},
+		{
+			name:     "Bracketed IPv6 portal",
+			link:     "ip-[fd00:c1::a9fe:202]:3260-iscsi-" + iqn + "-lun-2",
+			want:     iscsiByPathLink{ip: net.ParseIP("fd00:c1::a9fe:202"), port: 3260, iqn: iqn, lun: 2},
+			wantName: "ip-[fd00:c1::a9fe:202]:3260-iscsi-" + iqn + "-lun-2",
+			wantOk:   true,
+		},
+		{
+			name: "Paravirtualized link",
+			link: "pci-0000:00:04.0-scsi-0:0:0:4",
+		},
+		{
+			name: "Invalid portal IP",
+			link: "ip-169.254.2:3260-iscsi-" + iqn + "-lun-1",
+		},
+		{
+			name: "Missing lun",
+			link: "ip-169.254.2.2:3260-iscsi-" + iqn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseISCSIByPathLink(tt.link)
+			if ok != tt.wantOk {
+				t.Fatalf("parseISCSIByPathLink() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if !got.ip.Equal(tt.want.ip) || got.port != tt.want.port || got.iqn != tt.want.iqn || got.lun != tt.want.lun {
+				t.Errorf("parseISCSIByPathLink() = %+v, want %+v", got, tt.want)
+			}
+			if got.String() != tt.wantName {
+				t.Errorf("parseISCSIByPathLink().String() = %q, want %q", got.String(), tt.wantName)
+			}
+		})
+	}
+}
+
+func Test_listByPathLinksForTarget(t *testing.T) {
+	iqn := "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca"
+	otherIqn := "iqn.2015-12.com.oracleiaas:0f8e1c2d-1111-4a75-82d0-ee31a39471ca"
+	byPathDir := t.TempDir()
+	for _, name := range []string{
+		"ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-1",
+		"ip-169.254.2.3:3260-iscsi-" + iqn + "-lun-1",
+		"ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-2",
+		"ip-169.254.2.2:3260-iscsi-" + otherIqn + "-lun-1",
+		"ip-fd00:c1::a9fe:202:3260-iscsi-" + iqn + "-lun-3",
+		"pci-0000:00:04.0-scsi-0:0:0:4",
+	} {
+		if err := os.Symlink("../../sdb", filepath.Join(byPathDir, name)); err != nil {
+			t.Fatalf("failed to create link %s: %v", name, err)
+		}
+	}
+	link := func(name string) string {
+		return filepath.Join(byPathDir, name)
+	}
+
+	tests := []struct {
+		name      string
+		byPathDir string
+		disk      *disk.Disk
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "Links of a single portal",
+			byPathDir: byPathDir,
+			disk:      &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			want: []string{
+				link("ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-1"),
+				link("ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-2"),
+			},
+		},
+		{
+			name:      "Links of every portal of the target",
+			byPathDir: byPathDir,
+			disk:      &disk.Disk{IQN: iqn},
+			want: []string{
+				link("ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-1"),
+				link("ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-2"),
+				link("ip-169.254.2.3:3260-iscsi-" + iqn + "-lun-1"),
+				link("ip-fd00:c1::a9fe:202:3260-iscsi-" + iqn + "-lun-3"),
+			},
+		},
+		{
+			name:      "Links of an IPv6 portal",
+			byPathDir: byPathDir,
+			disk:      &disk.Disk{IQN: iqn, IscsiIp: "[fd00:00c1::a9fe:202]", Port: 3260},
+			want:      []string{link("ip-fd00:c1::a9fe:202:3260-iscsi-" + iqn + "-lun-3")},
+		},
+		{
+			name:      "No links of the target",
+			byPathDir: byPathDir,
+			disk:      &disk.Disk{IQN: iqn, IscsiIp: "169.254.2.4", Port: 3260},
+			want:      nil,
+		},
+		{
+			name:      "Missing IQN",
+			byPathDir: byPathDir,
+			disk:      &disk.Disk{IscsiIp: "169.254.2.2", Port: 3260},
+			wantErr:   true,
+		},
+		{
+			name:      "Invalid iSCSI IP",
+			byPathDir: byPathDir,
+			disk:      &disk.Disk{IQN: iqn, IscsiIp: "169.254.2", Port: 3260},
+			wantErr:   true,
+		},
+		{
+			name:      "Missing by-path directory",
+			byPathDir: filepath.Join(byPathDir, "missing"),
+			disk:      &disk.Disk{IQN: iqn},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := listByPathLinksForTarget(tt.byPathDir, tt.disk)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listByPathLinksForTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listByPathLinksForTarget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pruneStaleByPathLinks(t *testing.T) {
+	iqn := "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca"
+	devDir := t.TempDir()
+	device := filepath.Join(devDir, "sdb")
+	if err := os.WriteFile(device, nil, 0600); err != nil {
+		t.Fatalf("failed to create device %s: %v", device, err)
+	}
+	missingDevice := filepath.Join(devDir, "sdc")
+
+	tests := []struct {
+		name  string
+		links map[string]string
+		want  []string
+	}{
+		{
+			name: "Dangling iSCSI and paravirtualized links are pruned",
+			links: map[string]string{
+				"ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-1": missingDevice,
+				"ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-2": device,
+				"pci-0000:00:04.0-scsi-0:0:0:4":               missingDevice,
+				"pci-0000:00:04.0-scsi-0:0:0:5":               device,
+			},
+			want: []string{
+				"ip-169.254.2.2:3260-iscsi-" + iqn + "-lun-1",
+				"pci-0000:00:04.0-scsi-0:0:0:4",
+			},
+		},
+		{
+			name: "Dangling links of other devices are left alone",
+			links: map[string]string{
+				"pci-0000:00:1f.2-ata-1":  missingDevice,
+				"virtio-pci-0000:00:05.0": missingDevice,
+			},
+		},
+		{
+			name: "No dangling links",
+			links: map[string]string{
+				"ip-[fd00:c1::a9fe:202]:3260-iscsi-" + iqn + "-lun-3": device,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			byPathDir := t.TempDir()
+			for name, target := range tt.links {
+				if err := os.Symlink(target, filepath.Join(byPathDir, name)); err != nil {
+					t.Fatalf("failed to create link %s: %v", name, err)
+				}
+			}
+			var want []string
+			for _, name := range tt.want {
+				want = append(want, filepath.Join(byPathDir, name))
+			}
+
+			got, err := pruneStaleByPathLinks(zap.S(), byPathDir)
+			if err != nil {
+				t.Fatalf("pruneStaleByPathLinks() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("pruneStaleByPathLinks() = %v, want %v", got, want)
+			}
+			wantPruned := make(map[string]bool, len(want))
+			for _, link := range want {
+				wantPruned[link] = true
+			}
+			for name := range tt.links {
+				link := filepath.Join(byPathDir, name)
+				if _, err := os.Lstat(link); os.IsNotExist(err) != wantPruned[link] {
+					t.Errorf("link %s removed = %v, want %v", link, os.IsNotExist(err), wantPruned[link])
+				}
+			}
+		})
+	}
+
+	t.Run("Missing by-path directory", func(t *testing.T) {
+		if _, err := pruneStaleByPathLinks(zap.S(), filepath.Join(devDir, "missing")); err == nil {
+			t.Errorf("pruneStaleByPathLinks() expected an error for a missing directory")
+		}
+	})
+}
+
+func Test_WaitForDriverSockets(t *testing.T) {
+	dir := t.TempDir()
+	listen := func(t *testing.T, socketPath string, after time.Duration) {
+		go func() {
+			time.Sleep(after)
+			l, err := net.Listen("unix", socketPath)
+			if err != nil {
+				t.Errorf("failed to listen on %s: %v", socketPath, err)
+				return
+			}
+			t.Cleanup(func() { l.Close() })
+		}()
+	}
+
+	tests := []struct {
+		name      string
+		endpoints func(t *testing.T) []string
+		timeout   time.Duration
+		wantErr   bool
+	}{
+		{
+			name: "Sockets already listening and coming up late",
+			endpoints: func(t *testing.T) []string {
+				bv := filepath.Join(dir, "bv.sock")
+				fss := filepath.Join(dir, "fss.sock")
+				listen(t, bv, 0)
+				listen(t, fss, 300*time.Millisecond)
+				return []string{"unix://" + bv, fss}
+			},
+			timeout: 5 * time.Second,
+		},
+		{
+			name: "Socket never comes up",
+			endpoints: func(t *testing.T) []string {
+				return []string{"unix://" + filepath.Join(dir, "lustre.sock")}
+			},
+			timeout: 300 * time.Millisecond,
+			wantErr: true,
+		},
+		{
+			name: "Non unix endpoint",
+			endpoints: func(t *testing.T) []string {
+				return []string{"tcp://127.0.0.1:10000"}
+			},
+			timeout: 300 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WaitForDriverSockets(tt.endpoints(t), tt.timeout)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WaitForDriverSockets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_StripOCIScheme(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Prefixed provider id",
+			input: "oci://ocid1.instance.oc1.phx.aaaa",
+			want:  "ocid1.instance.oc1.phx.aaaa",
+		},
+		{
+			name:  "Unprefixed provider id",
+			input: "ocid1.instance.oc1.phx.aaaa",
+			want:  "ocid1.instance.oc1.phx.aaaa",
+		},
+		{
+			name:  "Scheme only stripped once",
+			input: "oci://oci://ocid1.instance.oc1.phx.aaaa",
+			want:  "oci://ocid1.instance.oc1.phx.aaaa",
+		},
+		{
+			name:  "Empty input",
+			input: "",
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripOCIScheme(tt.input); got != tt.want {
+				t.Errorf("StripOCIScheme() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_FilesystemResizeSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		fsType      string
+		deviceBytes int64
+		want        int64
+		wantErr     bool
+	}{
+		{
+			name:        "ext4 in 4KiB blocks",
+			fsType:      "ext4",
+			deviceBytes: 50 * client.GiB,
+			want:        50 * client.GiB / (4 * client.KiB),
+		},
+		{
+			name:        "ext3 rounds down partial blocks",
+			fsType:      "ext3",
+			deviceBytes: 50*client.GiB + 1024,
+			want:        50 * client.GiB / (4 * client.KiB),
+		},
+		{
+			name:        "xfs grows to whole device",
+			fsType:      "xfs",
+			deviceBytes: 50 * client.GiB,
+			want:        -1,
+		},
+		{
+			name:        "Device smaller than a block",
+			fsType:      "ext4",
+			deviceBytes: 512,
+			wantErr:     true,
+		},
+		{
+			name:        "Invalid device size",
+			fsType:      "xfs",
+			deviceBytes: 0,
+			wantErr:     true,
+		},
+		{
+			name:        "Unsupported fsType",
+			fsType:      "btrfs",
+			deviceBytes: 50 * client.GiB,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilesystemResizeSize(tt.fsType, tt.deviceBytes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FilesystemResizeSize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FilesystemResizeSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidatePerformanceDowngrade(t *testing.T) {
+	tests := []struct {
+		name          string
+		currentVpus   int64
+		requestedVpus int64
+		wantCode      codes.Code
+	}{
+		{
+			name:          "Upgrade",
+			currentVpus:   BalancedPerformanceOption,
+			requestedVpus: HigherPerformanceOption,
+			wantCode:      codes.OK,
+		},
+		{
+			name:          "Same performance",
+			currentVpus:   30,
+			requestedVpus: 30,
+			wantCode:      codes.OK,
+		},
+		{
+			name:          "Downgrade within the Ultra High Performance range",
+			currentVpus:   50,
+			requestedVpus: 30,
+			wantCode:      codes.OK,
+		},
+		{
+			name:          "Downgrade below Ultra High Performance is restricted",
+			currentVpus:   30,
+			requestedVpus: HigherPerformanceOption,
+			wantCode:      codes.FailedPrecondition,
+		},
+		{
+			name:          "Downgrade to lower cost",
+			currentVpus:   HigherPerformanceOption,
+			requestedVpus: LowCostPerformanceOption,
+			wantCode:      codes.OK,
+		},
+		{
+			name:          "Requested performance out of range",
+			currentVpus:   BalancedPerformanceOption,
+			requestedVpus: -10,
+			wantCode:      codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePerformanceDowngrade(tt.currentVpus, tt.requestedVpus)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("ValidatePerformanceDowngrade() code = %v, want %v, error = %v", got, tt.wantCode, err)
+			}
+		})
+	}
+}
+
+func Test_ValidatePerformanceForAccessType(t *testing.T) {
+	tests := []struct {
+		name      string
+		vpusPerGB int64
+		isBlock   bool
+		wantErr   bool
+	}{
+		{
+			name:      "Balanced performance raw block volume",
+			vpusPerGB: BalancedPerformanceOption,
+			isBlock:   true,
+		},
+		{
+			name:      "Higher performance raw block volume",
+			vpusPerGB: HigherPerformanceOption,
+			isBlock:   true,
+		},
+		{
+			name:      "Ultra high performance filesystem volume",
+			vpusPerGB: 30,
+			isBlock:   false,
+		},
+		{
+			name:      "Performance level below the raw block restriction",
+			vpusPerGB: MinRawBlockRestrictedPerformanceOption - 1,
+			isBlock:   true,
+		},
+		{
+			name:      "Lowest restricted raw block performance level",
+			vpusPerGB: MinRawBlockRestrictedPerformanceOption,
+			isBlock:   true,
+			wantErr:   true,
+		},
+		{
+			name:      "Ultra high performance raw block volume",
+			vpusPerGB: 30,
+			isBlock:   true,
+			wantErr:   true,
+		},
+		{
+			name:      "Max ultra high performance raw block volume",
+			vpusPerGB: MaxUltraHighPerformanceOption,
+			isBlock:   true,
+			wantErr:   true,
+		},
+		{
+			name:      "Performance level out of range",
+			vpusPerGB: MaxUltraHighPerformanceOption + 10,
+			isBlock:   false,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePerformanceForAccessType(tt.vpusPerGB, tt.isBlock)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePerformanceForAccessType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ExtractWWID(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "WWID present",
+			attributes: map[string]string{disk.ISCSIIQN: "iqn.2015-12.com.oracleiaas:63a2e76c", disk.SCSIWWID: "360f5b4e7d1c14c7e8b2a1a5d1c3f9e21"},
+			want:       "360f5b4e7d1c14c7e8b2a1a5d1c3f9e21",
+		},
+		{
+			name:       "WWID normalised to lower case",
+			attributes: map[string]string{disk.SCSIWWID: " 360F5B4E7D1C14C7E8B2A1A5D1C3F9E21 "},
+			want:       "360f5b4e7d1c14c7e8b2a1a5d1c3f9e21",
+		},
+		{
+			name:       "WWID absent",
+			attributes: map[string]string{disk.ISCSIIQN: "iqn.2015-12.com.oracleiaas:63a2e76c"},
+			want:       "",
+		},
+		{
+			name:       "Invalid WWID",
+			attributes: map[string]string{disk.SCSIWWID: "mpatha"},
+			wantErr:    true,
+		},
+		{
+			name:       "Empty WWID",
+			attributes: map[string]string{disk.SCSIWWID: ""},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractWWID(tt.attributes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExtractWWID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ExtractWWID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidateEncryptionWithIpFamily(t *testing.T) {
+	ipv4Node := &NodeMetadata{Ipv4Enabled: true}
+	ipv6Node := &NodeMetadata{Ipv6Enabled: true}
+	dualStackNode := &NodeMetadata{Ipv4Enabled: true, Ipv6Enabled: true}
+	tests := []struct {
+		name         string
+		encrypted    bool
+		nodeMetadata *NodeMetadata
+		wantErr      bool
+	}{
+		{
+			name:         "Encrypted mount on ipv4 node",
+			encrypted:    true,
+			nodeMetadata: ipv4Node,
+		},
+		{
+			name:         "Encrypted mount on dual stack node",
+			encrypted:    true,
+			nodeMetadata: dualStackNode,
+		},
+		{
+			name:         "Encrypted mount on ipv6 single stack node",
+			encrypted:    true,
+			nodeMetadata: ipv6Node,
+			wantErr:      true,
+		},
+		{
+			name:         "Unencrypted mount on ipv6 single stack node",
+			encrypted:    false,
+			nodeMetadata: ipv6Node,
+		},
+		{
+			name:      "Encrypted mount without node metadata",
+			encrypted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEncryptionWithIpFamily(tt.encrypted, tt.nodeMetadata)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEncryptionWithIpFamily() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ResolveAttachIpFamily(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodeMetadata *NodeMetadata
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:         "Ipv4 single stack node",
+			nodeMetadata: &NodeMetadata{Ipv4Enabled: true},
+			want:         Ipv4Stack,
+		},
+		{
+			name:         "Ipv6 single stack node ignores preference",
+			nodeMetadata: &NodeMetadata{PreferredNodeIpFamily: Ipv4Stack, Ipv6Enabled: true},
+			want:         Ipv6Stack,
+		},
+		{
+			name:         "Dual stack node without preference defaults to ipv4",
+			nodeMetadata: &NodeMetadata{Ipv4Enabled: true, Ipv6Enabled: true},
+			want:         Ipv4Stack,
+		},
+		{
+			name:         "Dual stack node preferring ipv6",
+			nodeMetadata: &NodeMetadata{PreferredNodeIpFamily: Ipv6Stack, Ipv4Enabled: true, Ipv6Enabled: true},
+			want:         Ipv6Stack,
+		},
+		{
+			name:         "Dual stack node preferring ipv4 in lower case",
+			nodeMetadata: &NodeMetadata{PreferredNodeIpFamily: "ipv4", Ipv4Enabled: true, Ipv6Enabled: true},
+			want:         Ipv4Stack,
+		},
+		{
+			name:         "Dual stack node with invalid preference",
+			nodeMetadata: &NodeMetadata{PreferredNodeIpFamily: "IPv5", Ipv4Enabled: true, Ipv6Enabled: true},
+			wantErr:      true,
+		},
+		{
+			name:         "No ip family enabled",
+			nodeMetadata: &NodeMetadata{},
+			wantErr:      true,
+		},
+		{
+			name:    "Nil node metadata",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveAttachIpFamily(tt.nodeMetadata)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResolveAttachIpFamily() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ResolveAttachIpFamily() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidateMountTargetFamily(t *testing.T) {
+	ipv4Node := &NodeMetadata{Ipv4Enabled: true}
+	ipv6Node := &NodeMetadata{Ipv6Enabled: true}
+	dualStackNode := &NodeMetadata{Ipv4Enabled: true, Ipv6Enabled: true}
+	tests := []struct {
+		name         string
+		mountTarget  string
+		nodeMetadata *NodeMetadata
+		wantErr      bool
+	}{
+		{
+			name:         "Ipv4 mount target on ipv4 node",
+			mountTarget:  "10.0.2.44",
+			nodeMetadata: ipv4Node,
+		},
+		{
+			name:         "Ipv6 mount target on ipv6 node",
+			mountTarget:  "fd00:c1::a9fe:202",
+			nodeMetadata: ipv6Node,
+		},
+		{
+			name:         "Bracketed ipv6 mount target on dual stack node",
+			mountTarget:  "[fd00:c1::a9fe:202]",
+			nodeMetadata: dualStackNode,
+		},
+		{
+			name:         "Ipv4 mount target on dual stack node",
+			mountTarget:  "10.0.2.44",
+			nodeMetadata: dualStackNode,
+		},
+		{
+			name:         "Ipv6 mount target on ipv4 node",
+			mountTarget:  "fd00:c1::a9fe:202",
+			nodeMetadata: ipv4Node,
+			wantErr:      true,
+		},
+		{
+			name:         "Ipv4 mount target on ipv6 node",
+			mountTarget:  "10.0.2.44",
+			nodeMetadata: ipv6Node,
+			wantErr:      true,
+		},
+		{
+			name:         "Dns mount target on ipv6 node",
+			mountTarget:  "myhostname.subnet123.dnslabel.oraclevcn.com",
+			nodeMetadata: ipv6Node,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMountTargetFamily(tt.mountTarget, tt.nodeMetadata)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMountTargetFamily() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_RegistrationSocketPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		kubeletRoot string
+		driverName  string
+		want        string
+	}{
+		{
+			name:        "Block volume driver",
+			kubeletRoot: "/var/lib/kubelet",
+			driverName:  "blockvolume.csi.oraclecloud.com",
+			want:        "/var/lib/kubelet/plugins/blockvolume.csi.oraclecloud.com/csi.sock",
+		},
+		{
+			name:        "FSS driver",
+			kubeletRoot: "/var/lib/kubelet",
+			driverName:  "fss.csi.oraclecloud.com",
+			want:        "/var/lib/kubelet/plugins/fss.csi.oraclecloud.com/csi.sock",
+		},
+		{
+			name:        "Lustre driver with custom kubelet root",
+			kubeletRoot: "/data/kubelet/",
+			driverName:  "lustre.csi.oraclecloud.com",
+			want:        "/data/kubelet/plugins/lustre.csi.oraclecloud.com/csi.sock",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RegistrationSocketPath(tt.kubeletRoot, tt.driverName)
+			if got != tt.want {
+				t.Errorf("RegistrationSocketPath() = %v, want %v", got, tt.want)
+			}
+			if err := ValidateDriverSocketConfig(tt.driverName, "unix:///csi/"+csiSocketName, got); err != nil {
+				t.Errorf("ValidateDriverSocketConfig() rejected %s: %v", got, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateDriverName(t *testing.T) {
+	tests := []struct {
+		name       string
+		driverName string
+		wantErr    bool
+	}{
+		{name: "Block volume driver", driverName: "blockvolume.csi.oraclecloud.com"},
+		{name: "FSS driver", driverName: "fss.csi.oraclecloud.com"},
+		{name: "Dashes", driverName: "custom-block-volume.csi.example.com"},
+		{name: "Empty name", driverName: "", wantErr: true},
+		{name: "Too long", driverName: strings.Repeat("a", 54) + ".csi.oraclecloud.com", wantErr: true},
+		{name: "Leading dot", driverName: ".csi.oraclecloud.com", wantErr: true},
+		{name: "Trailing dash", driverName: "fss.csi.oraclecloud.com-", wantErr: true},
+		{name: "Invalid characters", driverName: "fss/csi.oraclecloud.com", wantErr: true},
+		{name: "Not in reverse domain form", driverName: "blockvolume", wantErr: true},
+		{name: "Underscore", driverName: "foo_bar.csi.com", wantErr: true},
+		{name: "Uppercase", driverName: "Foo.CSI.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDriverName(tt.driverName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDriverName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeFileInfo reports the given mode and nothing else.
+type fakeFileInfo struct {
+	os.FileInfo
+	mode os.FileMode
+}
+
+func (f fakeFileInfo) Mode() os.FileMode { return f.mode }
+
+func Test_supportsRawBlock(t *testing.T) {
+	statAs := func(mode os.FileMode, err error) func(string) (os.FileInfo, error) {
+		return func(name string) (os.FileInfo, error) {
+			if name != loopControlPath {
+				t.Errorf("stat(%q), want %q", name, loopControlPath)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return fakeFileInfo{mode: mode}, nil
+		}
+	}
+	lookPathAs := func(err error) func(string) (string, error) {
+		return func(file string) (string, error) {
+			if err != nil {
+				return "", err
+			}
+			return "/usr/sbin/" + file, nil
+		}
+	}
+	charDevice := os.ModeDevice | os.ModeCharDevice | 0600
+
+	tests := []struct {
+		name     string
+		stat     func(name string) (os.FileInfo, error)
+		lookPath func(file string) (string, error)
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "Loop driver and blockdev available",
+			stat:     statAs(charDevice, nil),
+			lookPath: lookPathAs(nil),
+			want:     true,
+		},
+		{
+			name:     "Loop control is not a character device",
+			stat:     statAs(0644, nil),
+			lookPath: lookPathAs(nil),
+			want:     false,
+		},
+		{
+			name:     "Loop driver missing",
+			stat:     statAs(0, os.ErrNotExist),
+			lookPath: lookPathAs(nil),
+			want:     false,
+		},
+		{
+			name:     "Loop control not readable",
+			stat:     statAs(0, os.ErrPermission),
+			lookPath: lookPathAs(nil),
+			wantErr:  true,
+		},
+		{
+			name:     "Blockdev not installed",
+			stat:     statAs(charDevice, nil),
+			lookPath: lookPathAs(exec.ErrNotFound),
+			want:     false,
+		},
+		{
+			name:     "Blockdev lookup fails",
+			stat:     statAs(charDevice, nil),
+			lookPath: lookPathAs(os.ErrPermission),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := supportsRawBlock(tt.stat, tt.lookPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("supportsRawBlock() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("supportsRawBlock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidateAgainstSoftCap(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestedBytes int64
+		softCapBytes   int64
+		wantErr        bool
+	}{
+		{
+			name:           "Under the soft cap",
+			requestedBytes: 50 * client.GiB,
+			softCapBytes:   100 * client.GiB,
+		},
+		{
+			name:           "At the soft cap",
+			requestedBytes: 100 * client.GiB,
+			softCapBytes:   100 * client.GiB,
+		},
+		{
+			name:           "Over the soft cap",
+			requestedBytes: 100*client.GiB + 1,
+			softCapBytes:   100 * client.GiB,
+			wantErr:        true,
+		},
+		{
+			name:           "No soft cap configured",
+			requestedBytes: 32 * client.TiB,
+			softCapBytes:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAgainstSoftCap(tt.requestedBytes, tt.softCapBytes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAgainstSoftCap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_ToQuantityString(t *testing.T) {
+	tests := []struct {
+		name  string
+		bytes int64
+		want  string
+	}{
+		{
+			name:  "Zero bytes",
+			bytes: 0,
+			want:  "0",
+		},
+		{
+			name:  "Whole GiB",
+			bytes: 50 * client.GiB,
+			want:  "50Gi",
+		},
+		{
+			name:  "Whole TiB",
+			bytes: client.TiB,
+			want:  "1Ti",
+		},
+		{
+			name:  "Fractional GiB",
+			bytes: 1536 * client.MiB,
+			want:  "1536Mi",
+		},
+		{
+			name:  "Not a multiple of KiB",
+			bytes: 1000,
+			want:  "1k",
+		},
+		{
+			name:  "Odd number of bytes",
+			bytes: 1023,
+			want:  "1023",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToQuantityString(tt.bytes)
+			if got != tt.want {
+				t.Errorf("ToQuantityString() = %v, want %v", got, tt.want)
+			}
+			q, err := resource.ParseQuantity(got)
+			if err != nil {
+				t.Fatalf("ToQuantityString() = %v, failed to parse as quantity: %v", got, err)
+			}
+			if q.Value() != tt.bytes {
+				t.Errorf("ToQuantityString() = %v, round-trips to %d, want %d", got, q.Value(), tt.bytes)
+			}
+		})
+	}
+}
+
+func Test_QuantityToBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{
+			name: "GiB quantity",
+			s:    "50Gi",
+			want: 50 * client.GiB,
+		},
+		{
+			name: "TiB quantity",
+			s:    "1Ti",
+			want: client.TiB,
+		},
+		{
+			name: "Plain bytes",
+			s:    "1024",
+			want: client.KiB,
+		},
+		{
+			name:    "Malformed quantity",
+			s:       "50GiB",
+			wantErr: true,
+		},
+		{
+			name:    "Empty quantity",
+			s:       "",
+			wantErr: true,
+		},
+		{
+			name:    "Negative quantity",
+			s:       "-1Gi",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := QuantityToBytes(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("QuantityToBytes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("QuantityToBytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ExtractFssExportFromMount(t *testing.T) {
+	tests := []struct {
+		name       string
+		mountLine  string
+		wantServer string
+		wantExport string
+		wantErr    bool
+	}{
+		{
+			name:       "IPv4 server",
+			mountLine:  "10.0.10.5:/FileSystem-1 /var/lib/kubelet/plugins/kubernetes.io/csi/fss.csi.oraclecloud.com/abc/globalmount nfs rw,relatime,vers=3 0 0",
+			wantServer: "10.0.10.5",
+			wantExport: "/FileSystem-1",
+		},
+		{
+			name:       "Bracketed IPv6 server",
+			mountLine:  "[fd00:c1::a9fe:a9fe]:/FileSystem-1 /mnt nfs rw,relatime,vers=3 0 0",
+			wantServer: "fd00:c1::a9fe:a9fe",
+			wantExport: "/FileSystem-1",
+		},
+		{
+			name:       "Unbracketed IPv6 server",
+			mountLine:  "fd00:c1::a9fe:a9fe:/FileSystem-1 /mnt nfs rw 0 0",
+			wantServer: "fd00:c1::a9fe:a9fe",
+			wantExport: "/FileSystem-1",
+		},
+		{
+			name:       "DNS server with nested export",
+			mountLine:  "mt.subnet.vcn.oraclevcn.com:/exports/team-a /mnt nfs rw 0 0",
+			wantServer: "mt.subnet.vcn.oraclevcn.com",
+			wantExport: "/exports/team-a",
+		},
+		{
+			name:      "Not an NFS source",
+			mountLine: "/dev/sdb /mnt ext4 rw 0 0",
+			wantErr:   true,
+		},
+		{
+			name:      "Missing closing bracket",
+			mountLine: "[fd00:c1::a9fe:a9fe:/FileSystem-1 /mnt nfs rw 0 0",
+			wantErr:   true,
+		},
+		{
+			name:      "Missing server",
+			mountLine: ":/FileSystem-1 /mnt nfs rw 0 0",
+			wantErr:   true,
+		},
+		{
+			name:      "Empty line",
+			mountLine: "",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, export, err := ExtractFssExportFromMount(tt.mountLine)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExtractFssExportFromMount() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if server != tt.wantServer || export != tt.wantExport {
+				t.Errorf("ExtractFssExportFromMount() = (%v, %v), want (%v, %v)", server, export, tt.wantServer, tt.wantExport)
+			}
+		})
+	}
+}
+
+func Test_ProbeISCSIPortal(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	listeningPort := listener.Addr().(*net.TCPAddr).Port
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		disk    *disk.Disk
+		wantErr bool
+	}{
+		{
+			name: "Listening portal",
+			disk: &disk.Disk{IscsiIp: "127.0.0.1", Port: listeningPort},
+		},
+		{
+			name:    "Non-listening portal",
+			disk:    &disk.Disk{IscsiIp: "127.0.0.1", Port: closedPort},
+			wantErr: true,
+		},
+		{
+			name:    "Nil disk",
+			disk:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ProbeISCSIPortal(context.Background(), tt.disk, time.Second)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProbeISCSIPortal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_RetriesFromDeadline(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		noTimer  bool
+		interval time.Duration
+		want     int
+	}{
+		{
+			name:     "No deadline",
+			noTimer:  true,
+			interval: time.Second,
+			want:     defaultWaitForPathRetries,
+		},
+		{
+			name:     "Deadline fits several intervals",
+			timeout:  10*time.Second + 500*time.Millisecond,
+			interval: time.Second,
+			want:     11,
+		},
+		{
+			name:     "Deadline shorter than interval",
+			timeout:  500 * time.Millisecond,
+			interval: time.Second,
+			want:     1,
+		},
+		{
+			name:     "Sub second interval",
+			timeout:  2*time.Second + 50*time.Millisecond,
+			interval: 500 * time.Millisecond,
+			want:     5,
+		},
+		{
+			name:     "Deadline already passed",
+			timeout:  -time.Second,
+			interval: time.Second,
+			want:     1,
+		},
+		{
+			name:     "Zero interval",
+			timeout:  time.Minute,
+			interval: 0,
+			want:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if !tt.noTimer {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			if got := RetriesFromDeadline(ctx, tt.interval); got != tt.want {
+				t.Errorf("RetriesFromDeadline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ExtractStorageFSS(t *testing.T) {
+	tests := []struct {
+		name     string
+		capRange *csi.CapacityRange
+		want     int64
+		wantErr  bool
+	}{
+		{
+			name:     "Nil capacity range is elastic",
+			capRange: nil,
+			want:     0,
+		},
+		{
+			name:     "Empty capacity range is elastic",
+			capRange: &csi.CapacityRange{},
+			want:     0,
+		},
+		{
+			name:     "Request below the block volume minimum",
+			capRange: &csi.CapacityRange{RequiredBytes: 1 * client.GiB},
+			want:     1 * client.GiB,
+		},
+		{
+			name:     "Request above the block volume maximum",
+			capRange: &csi.CapacityRange{RequiredBytes: 100 * client.TiB},
+			want:     100 * client.TiB,
+		},
+		{
+			name:     "Only limit set",
+			capRange: &csi.CapacityRange{LimitBytes: 10 * client.GiB},
+			want:     10 * client.GiB,
+		},
+		{
+			name:     "Required and limit set",
+			capRange: &csi.CapacityRange{RequiredBytes: 10 * client.GiB, LimitBytes: 20 * client.GiB},
+			want:     10 * client.GiB,
+		},
+		{
+			name:     "Limit less than required",
+			capRange: &csi.CapacityRange{RequiredBytes: 20 * client.GiB, LimitBytes: 10 * client.GiB},
+			wantErr:  true,
+		},
+		{
+			name:     "Negative required bytes",
+			capRange: &csi.CapacityRange{RequiredBytes: -1},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractStorageFSS(tt.capRange)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractStorageFSS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractStorageFSS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_CapacityRangeSatisfiedBy(t *testing.T) {
+	tests := []struct {
+		name        string
+		capRange    *csi.CapacityRange
+		actualBytes int64
+		want        bool
+	}{
+		{
+			name:        "Nil capacity range",
+			capRange:    nil,
+			actualBytes: 50 * client.GiB,
+			want:        true,
+		},
+		{
+			name:        "Required bytes already satisfied",
+			capRange:    &csi.CapacityRange{RequiredBytes: 50 * client.GiB},
+			actualBytes: 100 * client.GiB,
+			want:        true,
+		},
+		{
+			name:        "Exactly the required bytes",
+			capRange:    &csi.CapacityRange{RequiredBytes: 100 * client.GiB, LimitBytes: 100 * client.GiB},
+			actualBytes: 100 * client.GiB,
+			want:        true,
+		},
+		{
+			name:        "Needs to grow",
+			capRange:    &csi.CapacityRange{RequiredBytes: 200 * client.GiB},
+			actualBytes: 100 * client.GiB,
+			want:        false,
+		},
+		{
+			name:        "Over the limit",
+			capRange:    &csi.CapacityRange{RequiredBytes: 50 * client.GiB, LimitBytes: 80 * client.GiB},
+			actualBytes: 100 * client.GiB,
+			want:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CapacityRangeSatisfiedBy(tt.capRange, tt.actualBytes); got != tt.want {
+				t.Errorf("CapacityRangeSatisfiedBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ValidateMountOptions(t *testing.T) {
+	denylist := []string{"suid", "dev", "uid"}
+	tests := []struct {
+		name    string
+		opts    []string
+		wantErr bool
+	}{
+		{
+			name: "No mount options",
+			opts: nil,
+		},
+		{
+			name: "Allowed mount options",
+			opts: []string{"nosuid", "nodev", "noatime", "nfsvers=3"},
+		},
+		{
+			name:    "Denied mount option",
+			opts:    []string{"noatime", "suid"},
+			wantErr: true,
+		},
+		{
+			name:    "Denied mount option with value",
+			opts:    []string{"uid=0"},
+			wantErr: true,
+		},
+		{
+			name:    "Denied mount option in comma separated entry",
+			opts:    []string{"noatime,dev"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMountOptions(tt.opts, denylist)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMountOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_isDeviceReadOnly(t *testing.T) {
+	const devicePath = "/dev/sdb"
+	const getro = "blockdev --getro " + devicePath
+	tests := []struct {
+		name    string
+		runner  *fakeCommandRunner
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "Read-only device",
+			runner: &fakeCommandRunner{outputs: map[string][]byte{getro: []byte("1\n")}},
+			want:   true,
+		},
+		{
+			name:   "Read-write device",
+			runner: &fakeCommandRunner{outputs: map[string][]byte{getro: []byte("0\n")}},
+			want:   false,
+		},
+		{
+			name: "blockdev fails",
+			runner: &fakeCommandRunner{
+				outputs: map[string][]byte{getro: []byte("blockdev: cannot open /dev/sdb: No such file or directory\n")},
+				errs:    map[string]error{getro: fmt.Errorf("exit status 1")},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Unexpected output",
+			runner:  &fakeCommandRunner{outputs: map[string][]byte{getro: []byte("yes\n")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isDeviceReadOnly(zap.S(), tt.runner, devicePath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("isDeviceReadOnly() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("isDeviceReadOnly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_isFsTypeSupportedByKernel(t *testing.T) {
+	procFilesystems := filepath.Join(t.TempDir(), "filesystems")
+	if err := os.WriteFile(procFilesystems, []byte("nodev\tsysfs\nnodev\ttmpfs\n\text3\n\text4\nnodev\tnfs4\n"), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", procFilesystems, err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		fsType  string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "Supported block filesystem",
+			path:   procFilesystems,
+			fsType: "ext4",
+			want:   true,
+		},
+		{
+			name:   "Supported nodev filesystem",
+			path:   procFilesystems,
+			fsType: "nfs4",
+			want:   true,
+		},
+		{
+			name:   "Filesystem module not loaded",
+			path:   procFilesystems,
+			fsType: "xfs",
+			want:   false,
+		},
+		{
+			name:   "nodev marker is not a filesystem",
+			path:   procFilesystems,
+			fsType: "nodev",
+			want:   false,
+		},
+		{
+			name:    "Empty fsType",
+			path:    procFilesystems,
+			wantErr: true,
+		},
+		{
+			name:    "Missing proc filesystems file",
+			path:    filepath.Join(t.TempDir(), "missing"),
+			fsType:  "ext4",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isFsTypeSupportedByKernel(tt.path, tt.fsType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("isFsTypeSupportedByKernel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("isFsTypeSupportedByKernel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getInitiatorName(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Valid initiator name file",
+			path: writeFile("valid", "## DO NOT EDIT OR REMOVE THIS FILE!\n## If you remove this file, the iSCSI daemon will not start.\nInitiatorName=iqn.1988-12.com.oracle:4f3a2b1c9d8e\n"),
+			want: "iqn.1988-12.com.oracle:4f3a2b1c9d8e",
+		},
+		{
+			name:    "Missing file",
+			path:    filepath.Join(dir, "missing"),
+			wantErr: true,
+		},
+		{
+			name:    "Malformed initiator name",
+			path:    writeFile("malformed", "InitiatorName=not-an-iqn\n"),
+			wantErr: true,
+		},
+		{
+			name:    "No initiator name",
+			path:    writeFile("empty", "# InitiatorName=iqn.1988-12.com.oracle:4f3a2b1c9d8e\n"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getInitiatorName(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getInitiatorName() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("getInitiatorName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_CanChangePerformanceOnline(t *testing.T) {
+	tests := []struct {
+		name          string
+		currentVpus   int64
+		requestedVpus int64
+		want          bool
+	}{
+		{
+			name:          "Unchanged performance",
+			currentVpus:   BalancedPerformanceOption,
+			requestedVpus: BalancedPerformanceOption,
+			want:          true,
+		},
+		{
+			name:          "Lower cost to higher performance",
+			currentVpus:   LowCostPerformanceOption,
+			requestedVpus: HigherPerformanceOption,
+			want:          true,
+		},
+		{
+			name:          "Higher performance to balanced",
+			currentVpus:   HigherPerformanceOption,
+			requestedVpus: BalancedPerformanceOption,
+			want:          true,
+		},
+		{
+			name:          "Within ultra high performance",
+			currentVpus:   30,
+			requestedVpus: MaxUltraHighPerformanceOption,
+			want:          true,
+		},
+		{
+			name:          "Into ultra high performance",
+			currentVpus:   HigherPerformanceOption,
+			requestedVpus: 30,
+			want:          false,
+		},
+		{
+			name:          "Out of ultra high performance",
+			currentVpus:   40,
+			requestedVpus: BalancedPerformanceOption,
+			want:          false,
+		},
+		{
+			name:          "Unsupported requested performance",
+			currentVpus:   BalancedPerformanceOption,
+			requestedVpus: MaxUltraHighPerformanceOption + 10,
+			want:          false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanChangePerformanceOnline(tt.currentVpus, tt.requestedVpus); got != tt.want {
+				t.Errorf("CanChangePerformanceOnline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ExtractBlockVolumePerformanceLevelOrDefault(t *testing.T) {
+	tests := []struct {
+		name      string
+		attribute string
+		want      int64
+		wantErr   bool
+	}{
+		{
+			name:      "Empty performance level",
+			attribute: "",
+			want:      BalancedPerformanceOption,
+		},
+		{
+			name:      "Valid performance level",
+			attribute: "20",
+			want:      HigherPerformanceOption,
+		},
+		{
+			name:      "Out of range performance level",
+			attribute: "130",
+			wantErr:   true,
+		},
+		{
+			name:      "Non numeric performance level",
+			attribute: "high",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractBlockVolumePerformanceLevelOrDefault(tt.attribute)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExtractBlockVolumePerformanceLevelOrDefault() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ExtractBlockVolumePerformanceLevelOrDefault() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_DetectPerformanceMismatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		volumeContext map[string]string
+		actualVpus    int64
+		want          bool
+		wantErr       bool
+	}{
+		{
+			name:          "Declared performance matches actual",
+			volumeContext: map[string]string{VpusPerGB: "20"},
+			actualVpus:    20,
+			want:          false,
+		},
+		{
+			name:          "Performance updated out of band",
+			volumeContext: map[string]string{VpusPerGB: "10"},
+			actualVpus:    30,
+			want:          true,
+		},
+		{
+			name:          "Missing performance level matches balanced",
+			volumeContext: map[string]string{},
+			actualVpus:    BalancedPerformanceOption,
+			want:          false,
+		},
+		{
+			name:          "Missing performance level differs from actual",
+			volumeContext: nil,
+			actualVpus:    LowCostPerformanceOption,
+			want:          true,
+		},
+		{
+			name:          "Invalid declared performance level",
+			volumeContext: map[string]string{VpusPerGB: "fast"},
+			actualVpus:    10,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectPerformanceMismatch(tt.volumeContext, tt.actualVpus)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectPerformanceMismatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DetectPerformanceMismatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_waitForUdevSettle(t *testing.T) {
+	expiredCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shortCancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		runner    *fakeCommandRunner
+		timeout   time.Duration
+		wantCalls []string
+		wantErr   bool
+	}{
+		{
+			name:      "udev settles",
+			ctx:       context.Background(),
+			runner:    &fakeCommandRunner{outputs: map[string][]byte{"udevadm settle --timeout=30": nil}},
+			timeout:   30 * time.Second,
+			wantCalls: []string{"udevadm settle --timeout=30"},
+		},
+		{
+			name:      "Timeout bounded by context deadline",
+			ctx:       shortCtx,
+			runner:    &fakeCommandRunner{outputs: map[string][]byte{"udevadm settle --timeout=5": nil}},
+			timeout:   30 * time.Second,
+			wantCalls: []string{"udevadm settle --timeout=5"},
+		},
+		{
+			name:      "udevadm missing",
+			ctx:       context.Background(),
+			runner:    &fakeCommandRunner{errs: map[string]error{"udevadm settle --timeout=30": exec.ErrNotFound}},
+			timeout:   30 * time.Second,
+			wantCalls: []string{"udevadm settle --timeout=30"},
+		},
+		{
+			name:      "udevadm times out",
+			ctx:       context.Background(),
+			runner:    &fakeCommandRunner{errs: map[string]error{"udevadm settle --timeout=30": fmt.Errorf("exit status 1")}},
+			timeout:   30 * time.Second,
+			wantCalls: []string{"udevadm settle --timeout=30"},
+			wantErr:   true,
+		},
+		{
+			name:    "Context already done",
+			ctx:     expiredCtx,
+			runner:  &fakeCommandRunner{},
+			timeout: 30 * time.Second,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := waitForUdevSettle(tt.ctx, tt.runner, tt.timeout, zap.S())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("waitForUdevSettle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tt.runner.calls, tt.wantCalls) {
+				t.Errorf("waitForUdevSettle() ran %q, want %q", tt.runner.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func Test_FormatBytes(t *testing.T) {
+	tests := []struct {
+		name       string
+		bytes      int64
+		want       string
+		wantParsed int64
+	}{
+		{name: "Zero", bytes: 0, want: "0", wantParsed: 0},
+		{name: "Bytes", bytes: 512, want: "512", wantParsed: 512},
+		{name: "Gibibytes", bytes: 50 * client.GiB, want: "50Gi", wantParsed: 50 * client.GiB},
+		{name: "Fractional tebibytes", bytes: 3 * client.TiB / 2, want: "1.5Ti", wantParsed: 3 * client.TiB / 2},
+		{name: "Pebibytes", bytes: 2 * client.PiB, want: "2Pi", wantParsed: 2 * client.PiB},
+		{name: "Exbibytes", bytes: 4 * client.EiB, want: "4Ei", wantParsed: 4 * client.EiB},
+		{name: "Not a whole number of gibibytes", bytes: client.GiB + 100*client.MiB, want: "1.1Gi", wantParsed: 1181116006},
+		{name: "One byte over a whole number of gibibytes", bytes: 50*client.GiB + 1, want: "50Gi", wantParsed: 50 * client.GiB},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatBytes(tt.bytes)
+			if got != tt.want {
+				t.Errorf("FormatBytes() = %v, want %v", got, tt.want)
+			}
+			if parsed, err := ParseBytes(got); err != nil || parsed != tt.wantParsed {
+				t.Errorf("ParseBytes(FormatBytes()) = %v, %v, want %v", parsed, err, tt.wantParsed)
+			}
+		})
+	}
+}
+
+func Test_ParseBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "Zero", input: "0", want: 0},
+		{name: "Bytes", input: "512", want: 512},
+		{name: "Gibibytes", input: "50Gi", want: 50 * client.GiB},
+		{name: "Tebibytes", input: "32Ti", want: 32 * client.TiB},
+		{name: "Fractional tebibytes", input: "1.5Ti", want: 3 * client.TiB / 2},
+		{name: "Pebibytes", input: "2Pi", want: 2 * client.PiB},
+		{name: "Exbibytes", input: "4Ei", want: 4 * client.EiB},
+		{name: "Rounded gibibytes", input: "1.3Gi", want: 1395864371},
+		{name: "Empty", input: "", wantErr: true},
+		{name: "Negative", input: "-1Gi", wantErr: true},
+		{name: "Unknown unit", input: "50GB", wantErr: true},
+		{name: "Unit without value", input: "Gi", wantErr: true},
+		{name: "Overflow", input: "8Ei", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBytes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBytes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseBytes() = %v, want %v", got, tt.want)
+			}
+			if !tt.wantErr && FormatBytes(got) != tt.input {
+				t.Errorf("FormatBytes(ParseBytes()) = %v, want %v", FormatBytes(got), tt.input)
+			}
+		})
+	}
+}
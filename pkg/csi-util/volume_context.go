@@ -0,0 +1,109 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/oracle/oci-cloud-controller-manager/pkg/util/disk"
+)
+
+const (
+	// VolumeContextFsTypeKey is the VolumeContext key holding the filesystem type.
+	VolumeContextFsTypeKey = "fsType"
+	// VolumeContextEncryptInTransitKey is the VolumeContext key holding whether
+	// in-transit encryption is enabled.
+	VolumeContextEncryptInTransitKey = "encryptInTransit"
+	// VolumeContextIpFamilyKey is the VolumeContext key holding the IP family
+	// the volume is attached over.
+	VolumeContextIpFamilyKey = "ipFamily"
+)
+
+// VolumeContext is the typed form of the VolumeContext attributes of a volume.
+type VolumeContext struct {
+	// Disk is the iSCSI target of the volume, nil when the context carries no
+	// iSCSI information, e.g. for paravirtualized attachments.
+	Disk *disk.Disk
+	// FsType is the filesystem type, empty when not set.
+	FsType string
+	// VpusPerGB is the performance level, BalancedPerformanceOption when not set.
+	VpusPerGB        int64
+	EncryptInTransit bool
+	// IpFamily is either Ipv4Stack or Ipv6Stack, empty when not set.
+	IpFamily string
+}
+
+// ParseVolumeContext validates the given VolumeContext attributes and returns
+// them as a VolumeContext. All fields are optional, but the iSCSI information
+// must be complete when any of it is present.
+func ParseVolumeContext(attributes map[string]string) (*VolumeContext, error) {
+	volumeContext := &VolumeContext{FsType: attributes[VolumeContextFsTypeKey]}
+
+	_, hasIQN := attributes[disk.ISCSIIQN]
+	_, hasIP := attributes[disk.ISCSIIP]
+	_, hasPort := attributes[disk.ISCSIPORT]
+	if hasIQN || hasIP || hasPort {
+		d, err := ExtractISCSIInformation(attributes)
+		if err != nil {
+			return nil, err
+		}
+		volumeContext.Disk = d
+	}
+
+	vpusPerGB, err := ExtractBlockVolumePerformanceLevelOrDefault(attributes[VpusPerGB])
+	if err != nil {
+		return nil, err
+	}
+	volumeContext.VpusPerGB = vpusPerGB
+
+	if encryptInTransit, ok := attributes[VolumeContextEncryptInTransitKey]; ok {
+		volumeContext.EncryptInTransit, err = strconv.ParseBool(encryptInTransit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q, must be a boolean", VolumeContextEncryptInTransitKey, encryptInTransit)
+		}
+	}
+
+	if ipFamily, ok := attributes[VolumeContextIpFamilyKey]; ok {
+		volumeContext.IpFamily = FormatValidIpStackInK8SConvention(ipFamily)
+		if volumeContext.IpFamily != Ipv4Stack && volumeContext.IpFamily != Ipv6Stack {
+			return nil, fmt.Errorf("invalid %s value %q, must be %s or %s", VolumeContextIpFamilyKey, ipFamily, Ipv4Stack, Ipv6Stack)
+		}
+	}
+
+	return volumeContext, nil
+}
+
+// ToMap returns the VolumeContext attributes of the volume, such that
+// ParseVolumeContext of the result yields an equal VolumeContext. Unset
+// optional fields are left out.
+func (vc *VolumeContext) ToMap() map[string]string {
+	attributes := map[string]string{
+		VpusPerGB:                        strconv.FormatInt(vc.VpusPerGB, 10),
+		VolumeContextEncryptInTransitKey: strconv.FormatBool(vc.EncryptInTransit),
+	}
+	if vc.Disk != nil {
+		attributes[disk.ISCSIIQN] = vc.Disk.IQN
+		attributes[disk.ISCSIIP] = vc.Disk.IscsiIp
+		attributes[disk.ISCSIPORT] = strconv.Itoa(vc.Disk.Port)
+	}
+	if vc.FsType != "" {
+		attributes[VolumeContextFsTypeKey] = vc.FsType
+	}
+	if vc.IpFamily != "" {
+		attributes[VolumeContextIpFamilyKey] = vc.IpFamily
+	}
+	return attributes
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csi_util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oracle/oci-cloud-controller-manager/pkg/util/disk"
+)
+
+func Test_ParseVolumeContext(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		want       *VolumeContext
+		wantErr    bool
+	}{
+		{
+			name: "Full context",
+			attributes: map[string]string{
+				disk.ISCSIIQN:                    "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+				disk.ISCSIIP:                     "169.254.2.2",
+				disk.ISCSIPORT:                   "3260",
+				VolumeContextFsTypeKey:           "xfs",
+				VpusPerGB:                        "20",
+				VolumeContextEncryptInTransitKey: "true",
+				VolumeContextIpFamilyKey:         "ipv6",
+			},
+			want: &VolumeContext{
+				Disk: &disk.Disk{
+					IQN:     "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+					IscsiIp: "169.254.2.2",
+					Port:    3260,
+				},
+				FsType:           "xfs",
+				VpusPerGB:        20,
+				EncryptInTransit: true,
+				IpFamily:         Ipv6Stack,
+			},
+		},
+		{
+			name:       "Empty context uses defaults",
+			attributes: map[string]string{},
+			want:       &VolumeContext{VpusPerGB: BalancedPerformanceOption},
+		},
+		{
+			name: "Missing iSCSI port",
+			attributes: map[string]string{
+				disk.ISCSIIQN: "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+				disk.ISCSIIP:  "169.254.2.2",
+			},
+			wantErr: true,
+		},
+		{
+			name:       "Missing iSCSI IQN",
+			attributes: map[string]string{disk.ISCSIIP: "169.254.2.2", disk.ISCSIPORT: "3260"},
+			wantErr:    true,
+		},
+		{
+			name:       "Invalid performance level",
+			attributes: map[string]string{VpusPerGB: "130"},
+			wantErr:    true,
+		},
+		{
+			name:       "Invalid encryption flag",
+			attributes: map[string]string{VolumeContextEncryptInTransitKey: "yes please"},
+			wantErr:    true,
+		},
+		{
+			name:       "Invalid ip family",
+			attributes: map[string]string{VolumeContextIpFamilyKey: "IPv5"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVolumeContext(tt.attributes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseVolumeContext() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseVolumeContext() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_VolumeContext_ToMap(t *testing.T) {
+	tests := []struct {
+		name          string
+		volumeContext *VolumeContext
+		want          map[string]string
+	}{
+		{
+			name: "Full context",
+			volumeContext: &VolumeContext{
+				Disk: &disk.Disk{
+					IQN:     "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+					IscsiIp: "169.254.2.2",
+					Port:    3260,
+				},
+				FsType:           "xfs",
+				VpusPerGB:        20,
+				EncryptInTransit: true,
+				IpFamily:         Ipv6Stack,
+			},
+			want: map[string]string{
+				disk.ISCSIIQN:                    "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+				disk.ISCSIIP:                     "169.254.2.2",
+				disk.ISCSIPORT:                   "3260",
+				VolumeContextFsTypeKey:           "xfs",
+				VpusPerGB:                        "20",
+				VolumeContextEncryptInTransitKey: "true",
+				VolumeContextIpFamilyKey:         Ipv6Stack,
+			},
+		},
+		{
+			name:          "Paravirtualized context without optional fields",
+			volumeContext: &VolumeContext{VpusPerGB: BalancedPerformanceOption},
+			want: map[string]string{
+				VpusPerGB:                        "10",
+				VolumeContextEncryptInTransitKey: "false",
+			},
+		},
+		{
+			name: "Ipv6 iSCSI target",
+			volumeContext: &VolumeContext{
+				Disk: &disk.Disk{
+					IQN:     "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+					IscsiIp: "fd00:c1::a9fe:202",
+					Port:    3260,
+				},
+				VpusPerGB: LowCostPerformanceOption,
+				IpFamily:  Ipv6Stack,
+			},
+			want: map[string]string{
+				disk.ISCSIIQN:                    "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+				disk.ISCSIIP:                     "fd00:c1::a9fe:202",
+				disk.ISCSIPORT:                   "3260",
+				VpusPerGB:                        "0",
+				VolumeContextEncryptInTransitKey: "false",
+				VolumeContextIpFamilyKey:         Ipv6Stack,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.volumeContext.ToMap()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToMap() = %v, want %v", got, tt.want)
+			}
+			roundTripped, err := ParseVolumeContext(got)
+			if err != nil {
+				t.Fatalf("ParseVolumeContext() error = %v", err)
+			}
+			if !reflect.DeepEqual(roundTripped, tt.volumeContext) {
+				t.Errorf("ParseVolumeContext(ToMap()) = %+v, want %+v", roundTripped, tt.volumeContext)
+			}
+		})
+	}
+}
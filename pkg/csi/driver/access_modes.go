@@ -0,0 +1,101 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	accessTypeMount = "Mount"
+	accessTypeBlock = "Block"
+)
+
+// volumeAccessType returns the access type (volumeMode in k8s) requested by
+// the capability, or "" when none is set.
+func volumeAccessType(capability *csi.VolumeCapability) string {
+	switch {
+	case capability.GetBlock() != nil:
+		return accessTypeBlock
+	case capability.GetMount() != nil:
+		return accessTypeMount
+	}
+	return ""
+}
+
+// accessModeSupport is an access mode a driver supports for one access type.
+type accessModeSupport struct {
+	accessType string
+	mode       csi.VolumeCapability_AccessMode_Mode
+}
+
+// supportedAccessModeTable returns the access modes the named driver supports
+// keyed by access type, derived from the capabilities each controller
+// advertises. Block volumes only allow MULTI_NODE_MULTI_WRITER (RWX) as a raw
+// block device, while FSS and Lustre only serve mounts.
+func supportedAccessModeTable(driverName string) []accessModeSupport {
+	var table []accessModeSupport
+	switch driverName {
+	case BlockVolumeDriverName:
+		for _, capability := range supportedVolumeCapabilities {
+			table = append(table, accessModeSupport{accessType: volumeAccessType(capability), mode: capability.GetAccessMode().GetMode()})
+		}
+	case FSSDriverName, LustreDriverName:
+		for i := range fssSupportedVolumeCapabilities {
+			table = append(table, accessModeSupport{accessType: accessTypeMount, mode: fssSupportedVolumeCapabilities[i].GetMode()})
+		}
+	}
+	return table
+}
+
+// SupportedAccessModes returns the access modes the named driver supports
+// for at least one access type. Lustre file systems are shared like FSS and
+// support the same modes. Unknown drivers support no modes. Use
+// ValidateAccessModeForDriver to check a mode against a given access type.
+func SupportedAccessModes(driverName string) []csi.VolumeCapability_AccessMode_Mode {
+	var modes []csi.VolumeCapability_AccessMode_Mode
+	seen := map[csi.VolumeCapability_AccessMode_Mode]bool{}
+	for _, supported := range supportedAccessModeTable(driverName) {
+		if !seen[supported.mode] {
+			seen[supported.mode] = true
+			modes = append(modes, supported.mode)
+		}
+	}
+	return modes
+}
+
+// ValidateAccessModeForDriver returns an InvalidArgument error when the named
+// driver does not support the capability's access mode with its access type,
+// e.g. RWX is accepted for a raw block volume but rejected for a mounted one.
+// FSS and Lustre treat a capability without an access type as a mount.
+func ValidateAccessModeForDriver(driverName string, capability *csi.VolumeCapability) error {
+	table := supportedAccessModeTable(driverName)
+	if len(table) == 0 {
+		return status.Errorf(codes.InvalidArgument, "unknown driver %q", driverName)
+	}
+	mode := capability.GetAccessMode().GetMode()
+	accessType := volumeAccessType(capability)
+	if accessType == "" && driverName != BlockVolumeDriverName {
+		accessType = accessTypeMount
+	}
+	for _, supported := range table {
+		if supported.accessType == accessType && supported.mode == mode {
+			return nil
+		}
+	}
+	return status.Errorf(codes.InvalidArgument, "invalid volume capabilities requested: AccessMode=%s AccessType=%s is not supported by driver %s", mode, accessType, driverName)
+}
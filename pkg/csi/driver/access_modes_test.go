@@ -0,0 +1,239 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_ValidateAccessModeForDriver(t *testing.T) {
+	mount := &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}}
+	block := &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}}
+	tests := []struct {
+		name       string
+		driverName string
+		accessType interface{}
+		mode       csi.VolumeCapability_AccessMode_Mode
+		wantErr    bool
+	}{
+		{
+			name:       "Block volume RWO mount",
+			driverName: BlockVolumeDriverName,
+			accessType: mount,
+			mode:       csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+		{
+			name:       "Block volume RWO raw block",
+			driverName: BlockVolumeDriverName,
+			accessType: block,
+			mode:       csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+		{
+			name:       "Block volume RWX raw block",
+			driverName: BlockVolumeDriverName,
+			accessType: block,
+			mode:       csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+		},
+		{
+			name:       "Block volume RWX mount",
+			driverName: BlockVolumeDriverName,
+			accessType: mount,
+			mode:       csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+			wantErr:    true,
+		},
+		{
+			name:       "Block volume without access type",
+			driverName: BlockVolumeDriverName,
+			mode:       csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			wantErr:    true,
+		},
+		{
+			name:       "Block volume ROX",
+			driverName: BlockVolumeDriverName,
+			accessType: mount,
+			mode:       csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY,
+			wantErr:    true,
+		},
+		{
+			name:       "Block volume multi node single writer",
+			driverName: BlockVolumeDriverName,
+			accessType: block,
+			mode:       csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER,
+			wantErr:    true,
+		},
+		{
+			name:       "FSS RWX",
+			driverName: FSSDriverName,
+			accessType: mount,
+			mode:       csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+		},
+		{
+			name:       "FSS ROX without access type",
+			driverName: FSSDriverName,
+			mode:       csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY,
+		},
+		{
+			name:       "FSS raw block",
+			driverName: FSSDriverName,
+			accessType: block,
+			mode:       csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+			wantErr:    true,
+		},
+		{
+			name:       "FSS RWOP",
+			driverName: FSSDriverName,
+			accessType: mount,
+			mode:       csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER,
+			wantErr:    true,
+		},
+		{
+			name:       "Lustre RWX",
+			driverName: LustreDriverName,
+			accessType: mount,
+			mode:       csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+		},
+		{
+			name:       "Unknown driver",
+			driverName: "unknown.csi.oraclecloud.com",
+			accessType: mount,
+			mode:       csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capability := &csi.VolumeCapability{AccessMode: &csi.VolumeCapability_AccessMode{Mode: tt.mode}}
+			switch accessType := tt.accessType.(type) {
+			case *csi.VolumeCapability_Mount:
+				capability.AccessType = accessType
+			case *csi.VolumeCapability_Block:
+				capability.AccessType = accessType
+			}
+			err := ValidateAccessModeForDriver(tt.driverName, capability)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAccessModeForDriver() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && status.Code(err) != codes.InvalidArgument {
+				t.Errorf("ValidateAccessModeForDriver() code = %v, want %v", status.Code(err), codes.InvalidArgument)
+			}
+		})
+	}
+}
+
+func Test_SupportedAccessModes(t *testing.T) {
+	sharedFilesystemModes := []csi.VolumeCapability_AccessMode_Mode{
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+	}
+	tests := []struct {
+		name       string
+		driverName string
+		want       []csi.VolumeCapability_AccessMode_Mode
+	}{
+		{
+			name:       "Block volume",
+			driverName: BlockVolumeDriverName,
+			// RWX is reported because raw block volumes support it, see
+			// Test_SupportedAccessModesAreValid
+			want: []csi.VolumeCapability_AccessMode_Mode{
+				csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+				csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+			},
+		},
+		{
+			name:       "FSS",
+			driverName: FSSDriverName,
+			want:       sharedFilesystemModes,
+		},
+		{
+			name:       "Lustre",
+			driverName: LustreDriverName,
+			want:       sharedFilesystemModes,
+		},
+		{
+			name:       "Unknown driver",
+			driverName: "unknown.csi.oraclecloud.com",
+			want:       nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SupportedAccessModes(tt.driverName); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SupportedAccessModes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_SupportedAccessModesAreValid(t *testing.T) {
+	accessTypes := map[string]*csi.VolumeCapability{
+		accessTypeMount: {AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}}},
+		accessTypeBlock: {AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}}},
+	}
+	tests := []struct {
+		name       string
+		driverName string
+		// want lists, per supported mode, the access types that accept it
+		want map[csi.VolumeCapability_AccessMode_Mode][]string
+	}{
+		{
+			name:       "Block volume",
+			driverName: BlockVolumeDriverName,
+			want: map[csi.VolumeCapability_AccessMode_Mode][]string{
+				csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER:      {accessTypeBlock, accessTypeMount},
+				csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER: {accessTypeBlock},
+			},
+		},
+		{
+			name:       "FSS",
+			driverName: FSSDriverName,
+			want: map[csi.VolumeCapability_AccessMode_Mode][]string{
+				csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER:       {accessTypeMount},
+				csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY:  {accessTypeMount},
+				csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY:   {accessTypeMount},
+				csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER: {accessTypeMount},
+				csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER:  {accessTypeMount},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[csi.VolumeCapability_AccessMode_Mode][]string{}
+			for _, mode := range SupportedAccessModes(tt.driverName) {
+				for _, accessType := range []string{accessTypeBlock, accessTypeMount} {
+					capability := &csi.VolumeCapability{
+						AccessType: accessTypes[accessType].AccessType,
+						AccessMode: &csi.VolumeCapability_AccessMode{Mode: mode},
+					}
+					if ValidateAccessModeForDriver(tt.driverName, capability) == nil {
+						got[mode] = append(got[mode], accessType)
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SupportedAccessModes() accepted by ValidateAccessModeForDriver = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -50,6 +50,7 @@ const (
 	timeout                       = time.Minute * 3
 	kmsKey                        = "kms-key-id"
 	attachmentType                = "attachment-type"
+	volumeSizeSoftCap             = "volumeSizeSoftCap"
 	attachmentTypeISCSI           = "iscsi"
 	attachmentTypeParavirtualized = "paravirtualized"
 	initialFreeformTagsOverride   = "oci.oraclecloud.com/initial-freeform-tags-override"
@@ -100,6 +101,8 @@ type VolumeParameters struct {
 	definedTags map[string]map[string]interface{}
 	//volume performance units per gb describes the block volume performance level
 	vpusPerGB int64
+	//softCapBytes is the operator configured maximum volume size for the storage class, 0 if not set
+	softCapBytes int64
 }
 
 // VolumeAttachmentOption holds config for attachments
@@ -177,6 +180,15 @@ func extractVolumeParameters(log *zap.SugaredLogger, parameters map[string]strin
 				return p, status.Error(codes.InvalidArgument, err.Error())
 			}
 			p.vpusPerGB = vpusPerGB
+		case volumeSizeSoftCap:
+			if v == "" {
+				continue
+			}
+			softCapBytes, err := csi_util.QuantityToBytes(v)
+			if err != nil || softCapBytes <= 0 {
+				return p, status.Errorf(codes.InvalidArgument, "invalid %s: %s provided for storageclass", volumeSizeSoftCap, v)
+			}
+			p.softCapBytes = softCapBytes
 		}
 
 	}
@@ -246,14 +258,19 @@ func (d *BlockVolumeControllerDriver) CreateVolume(ctx context.Context, req *csi
 		return nil, status.Error(codes.InvalidArgument, "VolumeCapabilities must be provided in CreateVolumeRequest")
 	}
 
-	if err := d.validateCapabilities(req.VolumeCapabilities, supportedVolumeCapabilities); err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+	for _, cap := range req.VolumeCapabilities {
+		if err := ValidateAccessModeForDriver(BlockVolumeDriverName, cap); err != nil {
+			log.With(zap.Error(err)).Error("The VolumeCapability isn't supported.")
+			return nil, err
+		}
 	}
 
 	size, err := csi_util.ExtractStorage(req.CapacityRange)
 	if err != nil {
 		return nil, status.Errorf(codes.OutOfRange, "invalid capacity range: %v", err)
 	}
+	// OCI creates volumes in whole GiB, so request what will actually be provisioned
+	size = csi_util.ToOCIAlignedBytes(size)
 
 	availableDomainShortName := ""
 	fullAvailabilityDomainName := ""
@@ -269,11 +286,16 @@ func (d *BlockVolumeControllerDriver) CreateVolume(ctx context.Context, req *csi
 		return nil, status.Errorf(codes.InvalidArgument, "failed to parse storageclass parameters %v", err)
 	}
 
+	if err := csi_util.ValidateAgainstSoftCap(size, volumeParams.softCapBytes); err != nil {
+		log.With(zap.Error(err)).Error("Requested size exceeds the storageclass soft cap.")
+		return nil, status.Errorf(codes.OutOfRange, "invalid capacity range: %v", err)
+	}
+
 	// Return error for the case of Raw Block Volume with Ultra High Performance Volumes
 	for _, cap := range req.VolumeCapabilities {
-		if blk := cap.GetBlock(); blk != nil && volumeParams.vpusPerGB >= 30 {
-			log.Error("volumeMode is set to Block for an Ultra High Performance Volume, which is not supported")
-			return nil, status.Errorf(codes.InvalidArgument, "failed to support Block volumeMode for Ultra High Performance Volumes (vpusPerGB >= 30)")
+		if err := csi_util.ValidatePerformanceForAccessType(volumeParams.vpusPerGB, cap.GetBlock() != nil); err != nil {
+			log.With(zap.Error(err)).Error("Unsupported performance level for the requested volumeMode.")
+			return nil, err
 		}
 	}
 
@@ -624,9 +646,12 @@ func (d *BlockVolumeControllerDriver) ControllerPublishVolume(ctx context.Contex
 		csiMetricDimension = util.GetMetricDimensionForComponent(errorType, util.CSIStorageType)
 		dimensionsMap[metrics.ComponentDimension] = csiMetricDimension
 		metrics.SendMetricData(d.metricPusher, csiMetricPrefix, time.Since(startTime).Seconds(), dimensionsMap)
+		if errors.Is(err, csi_util.ErrNodeNotFound) {
+			return nil, status.Errorf(codes.NotFound, "node %s not found. error : %s", req.NodeId, err)
+		}
 		return nil, status.Errorf(codes.InvalidArgument, "failed to get ProviderID by nodeName. error : %s", err)
 	}
-	id = client.MapProviderIDToInstanceID(id)
+	id = csi_util.StripOCIScheme(id)
 	dimensionsMap[metrics.InstanceIdDimension] = id
 
 	// if the attachmentType is missing, default is iscsi
@@ -942,7 +967,7 @@ func (d *BlockVolumeControllerDriver) ControllerUnpublishVolume(ctx context.Cont
 	}
 
 	// Handle possible oci:// prefix.
-	instanceID = client.MapProviderIDToInstanceID(instanceID)
+	instanceID = csi_util.StripOCIScheme(instanceID)
 	dimensionsMap[metrics.InstanceIdDimension] = instanceID
 
 	attachedVolume, err := d.client.Compute().FindVolumeAttachment(ctx, compartmentID, req.VolumeId, &instanceID)
@@ -1482,6 +1507,10 @@ func (d *BlockVolumeControllerDriver) ControllerModifyVolume(ctx context.Context
 func provision(ctx context.Context, log *zap.SugaredLogger, c client.Interface, volName string, volSize int64, availDomainName, compartmentID,
 	backupID, srcVolumeID, kmsKeyID string, vpusPerGB int64, bvTags *config.TagConfig) (core.Volume, error) {
 
+	if err := csi_util.ValidateOCIAlignedBytes(volSize); err != nil {
+		return core.Volume{}, err
+	}
+
 	volSizeGB, minSizeGB := csi_util.RoundUpSize(volSize, 1*client.GiB), csi_util.RoundUpMinSize()
 
 	if minSizeGB > volSizeGB {
@@ -1689,6 +1689,53 @@ func Test_extractStorage(t *testing.T) {
 			want:    100 * client.GiB,
 			wantErr: false,
 		},
+		{
+			name: "Required only above maximum",
+			args: args{capRange: &csi.CapacityRange{
+				RequiredBytes: 64 * client.TiB,
+			},
+			},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "Limit only above maximum",
+			args: args{capRange: &csi.CapacityRange{
+				LimitBytes: 64 * client.TiB,
+			},
+			},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "Required and limit both above maximum",
+			args: args{capRange: &csi.CapacityRange{
+				RequiredBytes: 40 * client.TiB,
+				LimitBytes:    64 * client.TiB,
+			},
+			},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "Required and limit within range",
+			args: args{capRange: &csi.CapacityRange{
+				RequiredBytes: 1 * client.TiB,
+				LimitBytes:    2 * client.TiB,
+			},
+			},
+			want:    2 * client.TiB,
+			wantErr: false,
+		},
+		{
+			name: "Required below minimum is clamped",
+			args: args{capRange: &csi.CapacityRange{
+				RequiredBytes: 10 * client.GiB,
+			},
+			},
+			want:    csi_util.MinimumVolumeSizeInBytes,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1721,6 +1768,40 @@ func TestExtractVolumeParameters(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		"StorageClass with volume size soft cap": {
+			storageParameters: map[string]string{
+				volumeSizeSoftCap: "100Gi",
+			},
+			volumeParameters: VolumeParameters{
+				diskEncryptionKey:   "",
+				attachmentParameter: make(map[string]string),
+				vpusPerGB:           10,
+				softCapBytes:        100 * client.GiB,
+			},
+			wantErr: false,
+		},
+		"StorageClass with invalid volume size soft cap": {
+			storageParameters: map[string]string{
+				volumeSizeSoftCap: "100Gb",
+			},
+			volumeParameters: VolumeParameters{
+				diskEncryptionKey:   "",
+				attachmentParameter: make(map[string]string),
+				vpusPerGB:           10,
+			},
+			wantErr: true,
+		},
+		"StorageClass with negative volume size soft cap": {
+			storageParameters: map[string]string{
+				volumeSizeSoftCap: "-100Gi",
+			},
+			volumeParameters: VolumeParameters{
+				diskEncryptionKey:   "",
+				attachmentParameter: make(map[string]string),
+				vpusPerGB:           10,
+			},
+			wantErr: true,
+		},
 		"StorageClass Parameters are empty": {
 			storageParameters: map[string]string{},
 			volumeParameters: VolumeParameters{
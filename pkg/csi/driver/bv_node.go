@@ -32,7 +32,6 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	kubeAPI "k8s.io/api/core/v1"
 	"k8s.io/kubernetes/pkg/volume"
 	"k8s.io/kubernetes/pkg/volume/util/hostutil"
 )
@@ -41,8 +40,13 @@ const (
 	maxVolumesPerNode               = 32
 	volumeOperationAlreadyExistsFmt = "An operation for the volume: %s already exists."
 	FSTypeXfs                       = "xfs"
+	// strictFsTypeFeatureFlagName enables rejecting unsupported fsTypes instead of defaulting to ext4
+	strictFsTypeFeatureFlagName = "CSI_ENABLE_STRICT_FSTYPE_VALIDATION"
 )
 
+// newISCSIMountHandler builds the iSCSI mount handler used by NodeStageVolume.
+var newISCSIMountHandler = disk.NewFromISCSIDisk
+
 // NodeStageVolume mounts the volume to a staging path on the node.
 func (d BlockVolumeNodeDriver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
 	if req.VolumeId == "" {
@@ -73,6 +77,30 @@ func (d BlockVolumeNodeDriver) NodeStageVolume(ctx context.Context, req *csi.Nod
 
 	logger.Infof("Is Volume Mode set to Raw Block Volume %s", isRawBlockVolume)
 
+	if isRawBlockVolume && !d.rawBlockSupported {
+		logger.Error("Raw block volumes are not supported on this node.")
+		return nil, status.Error(codes.FailedPrecondition, "Raw block volumes are not supported on this node")
+	}
+
+	// The fsType and mount options are validated before logging into the
+	// iSCSI target, so an invalid request fails without attaching.
+	var fsType string
+	var options []string
+	if !isRawBlockVolume {
+		mnt := req.VolumeCapability.GetMount()
+		options = mnt.GetMountFlags()
+		var err error
+		if d.strictFsTypeValidation {
+			fsType, err = csi_util.ValidateFsTypeStrict(logger, mnt.GetFsType(), options)
+		} else {
+			fsType, err = csi_util.ValidateFsType(logger, mnt.GetFsType(), options)
+		}
+		if err != nil {
+			logger.With(zap.Error(err)).Error("Invalid fsType and mount options.")
+			return nil, err
+		}
+	}
+
 	attachment, ok := req.PublishContext[attachmentType]
 
 	if !ok {
@@ -123,7 +151,7 @@ func (d BlockVolumeNodeDriver) NodeStageVolume(ctx context.Context, req *csi.Nod
 				}
 			}
 
-			mountHandler = disk.NewFromISCSIDisk(d.logger, scsiInfo)
+			mountHandler = newISCSIMountHandler(d.logger, scsiInfo)
 			logger.Info("starting to stage iSCSI Mounting.")
 		}
 	case attachmentTypeParavirtualized:
@@ -238,11 +266,6 @@ func (d BlockVolumeNodeDriver) NodeStageVolume(ctx context.Context, req *csi.Nod
 		return &csi.NodeStageVolumeResponse{}, nil
 	}
 
-	mnt := req.VolumeCapability.GetMount()
-	options := mnt.MountFlags
-
-	fsType := csi_util.ValidateFsType(logger, mnt.FsType)
-
 	exists := true
 	_, err = os.Stat(req.StagingTargetPath)
 	if err != nil {
@@ -415,28 +438,12 @@ func (d BlockVolumeNodeDriver) NodeUnstageVolume(ctx context.Context, req *csi.N
 		}
 	}
 
-	var unMountErr error
-
+	stagingPath := req.StagingTargetPath
 	if isRawBlockVolume {
-		unMountErr = mountHandler.UnmountPath(stagingTargetFilePath)
-	} else {
-		unMountErr = mountHandler.UnmountPath(req.StagingTargetPath)
-	}
-
-	if unMountErr != nil {
-		logger.With(zap.Error(unMountErr)).Error("failed to unmount the staging path")
-		return nil, status.Error(codes.Internal, unMountErr.Error())
+		stagingPath = stagingTargetFilePath
 	}
 
-	err = mountHandler.Logout()
-	if err != nil {
-		logger.With(zap.Error(err)).Error("failed to logout from the iSCSI target")
-		return nil, status.Error(codes.Internal, err.Error())
-	}
-
-	err = mountHandler.RemoveFromDB()
-	if err != nil {
-		logger.With(zap.Error(err)).Error("failed to remove the iSCSI node record")
+	if err := disk.CleanupStaging(logger, mountHandler, stagingPath); err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
@@ -463,6 +470,10 @@ func (d BlockVolumeNodeDriver) NodePublishVolume(ctx context.Context, req *csi.N
 		return nil, status.Error(codes.InvalidArgument, "Target Path must be provided")
 	}
 
+	if err := csi_util.ValidateDistinctPaths(req.StagingTargetPath, req.TargetPath); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	if req.VolumeCapability == nil {
 		return nil, status.Error(codes.InvalidArgument, "Volume Capability must be provided")
 	}
@@ -564,7 +575,11 @@ func (d BlockVolumeNodeDriver) NodePublishVolume(ctx context.Context, req *csi.N
 			options = append(options, "ro")
 		}
 
-		fsType := csi_util.ValidateFsType(logger, mnt.FsType)
+		fsType, err := csi_util.ValidateFsType(logger, mnt.FsType, mnt.MountFlags)
+		if err != nil {
+			logger.With(zap.Error(err)).Error("Invalid fsType and mount options.")
+			return nil, err
+		}
 
 		//XFS does not allow mounting two volumes with same UUID,
 		//this block is needed for mounting a volume and a volume
@@ -575,7 +590,7 @@ func (d BlockVolumeNodeDriver) NodePublishVolume(ctx context.Context, req *csi.N
 			}
 		}
 
-		err := mountHandler.Mount(req.StagingTargetPath, req.TargetPath, fsType, options)
+		err = mountHandler.Mount(req.StagingTargetPath, req.TargetPath, fsType, options)
 		if err != nil {
 			logger.With(zap.Error(err)).Error("failed to format and mount.")
 			return nil, status.Error(codes.Internal, err.Error())
@@ -791,6 +806,11 @@ func alreadyDeletedPathCheck(err error) bool {
 
 // NodeGetCapabilities returns the supported capabilities of the node server
 func (d BlockVolumeNodeDriver) NodeGetCapabilities(ctx context.Context, req *csi.NodeGetCapabilitiesRequest) (*csi.NodeGetCapabilitiesResponse, error) {
+	if !d.rawBlockSupported {
+		// The CSI spec has no node capability for raw block volumes, so they
+		// are rejected in NodeStageVolume instead.
+		d.logger.Warn("Raw block volumes are not supported on this node and will fail to stage.")
+	}
 	var nscaps []*csi.NodeServiceCapability
 	nodeCaps := []csi.NodeServiceCapability_RPC_Type{csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME, csi.NodeServiceCapability_RPC_GET_VOLUME_STATS, csi.NodeServiceCapability_RPC_EXPAND_VOLUME}
 	for _, nodeCap := range nodeCaps {
@@ -820,19 +840,10 @@ func (d BlockVolumeNodeDriver) NodeGetInfo(ctx context.Context, req *csi.NodeGet
 			return nil, status.Error(codes.Internal, "Failed to get availability domain of node from kube api server.")
 		}
 	}
-	segments := map[string]string{
-		kubeAPI.LabelZoneFailureDomain: d.nodeMetadata.AvailabilityDomain,
-		kubeAPI.LabelTopologyZone:      d.nodeMetadata.AvailabilityDomain,
-	}
-
-	//set full ad name in segments only for IPv6 single stack
-	if csi_util.IsIpv6SingleStackNode(d.nodeMetadata) {
-		if d.nodeMetadata.FullAvailabilityDomain == "" {
-			d.logger.With("nodeId", d.nodeID).Error("Failed to get full availability domain name of IPv6 single stack node from node labels.")
-			return nil, status.Error(codes.Internal, "Failed to get full availability domain name of IPv6 single stack node from node labels.")
-		}
-
-		segments[csi_util.AvailabilityDomainLabel] = d.nodeMetadata.FullAvailabilityDomain
+	segments, err := csi_util.NodeTopologySegments(d.nodeMetadata)
+	if err != nil {
+		d.logger.With(zap.Error(err)).With("nodeId", d.nodeID).Error("Failed to get topology segments of node from node labels.")
+		return nil, status.Error(codes.Internal, err.Error())
 	}
 
 	d.logger.With("nodeId", d.nodeID, "availabilityDomain", d.nodeMetadata.AvailabilityDomain).Info("Availability domain of node identified.")
@@ -15,8 +15,17 @@
 package driver
 
 import (
+	"context"
 	"fmt"
 	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	csi_util "github.com/oracle/oci-cloud-controller-manager/pkg/csi-util"
+	"github.com/oracle/oci-cloud-controller-manager/pkg/util/disk"
 )
 
 func Test_getDevicePathAndAttachmentType(t *testing.T) {
@@ -112,3 +121,138 @@ func Test_alreadyDeletedPathCheck(t *testing.T) {
 		})
 	}
 }
+
+// fakeISCSIMountHandler records the iSCSI calls NodeStageVolume makes up to
+// the login.
+type fakeISCSIMountHandler struct {
+	disk.Interface
+	calls []string
+}
+
+func (f *fakeISCSIMountHandler) IsMounted(devicePath, targetPath string) (bool, error) {
+	f.calls = append(f.calls, "IsMounted")
+	return false, nil
+}
+
+func (f *fakeISCSIMountHandler) AddToDB() error {
+	f.calls = append(f.calls, "AddToDB")
+	return nil
+}
+
+func (f *fakeISCSIMountHandler) UpdateQueueDepth() error {
+	f.calls = append(f.calls, "UpdateQueueDepth")
+	return nil
+}
+
+func (f *fakeISCSIMountHandler) SetAutomaticLogin() error {
+	f.calls = append(f.calls, "SetAutomaticLogin")
+	return nil
+}
+
+func (f *fakeISCSIMountHandler) Login() error {
+	f.calls = append(f.calls, "Login")
+	return fmt.Errorf("login not expected")
+}
+
+func TestBlockVolumeNodeDriver_NodeStageVolume_InvalidFsType(t *testing.T) {
+	tests := []struct {
+		name                   string
+		strictFsTypeValidation bool
+		mount                  *csi.VolumeCapability_MountVolume
+	}{
+		{
+			name:  "Mount option not supported by fsType",
+			mount: &csi.VolumeCapability_MountVolume{FsType: "ext4", MountFlags: []string{"prjquota"}},
+		},
+		{
+			name:                   "Unsupported fsType in strict mode",
+			strictFsTypeValidation: true,
+			mount:                  &csi.VolumeCapability_MountVolume{FsType: "ex4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &fakeISCSIMountHandler{}
+			defer func(f func(*zap.SugaredLogger, *disk.Disk) disk.Interface) { newISCSIMountHandler = f }(newISCSIMountHandler)
+			newISCSIMountHandler = func(*zap.SugaredLogger, *disk.Disk) disk.Interface { return handler }
+
+			d := BlockVolumeNodeDriver{
+				NodeDriver: NodeDriver{
+					logger:       zap.S(),
+					util:         &csi_util.Util{Logger: zap.S()},
+					volumeLocks:  csi_util.NewVolumeLocks(),
+					nodeMetadata: &csi_util.NodeMetadata{},
+				},
+				strictFsTypeValidation: tt.strictFsTypeValidation,
+			}
+			_, err := d.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+				VolumeId:          "ocid1.volume.oc1.phx.aaaa",
+				StagingTargetPath: t.TempDir(),
+				PublishContext: map[string]string{
+					attachmentType: attachmentTypeISCSI,
+					disk.ISCSIIQN:  "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca",
+					disk.ISCSIIP:   "10.0.0.2",
+					disk.ISCSIPORT: "3260",
+				},
+				VolumeCapability: &csi.VolumeCapability{
+					AccessType: &csi.VolumeCapability_Mount{Mount: tt.mount},
+				},
+			})
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("NodeStageVolume() error = %v, want code %v", err, codes.InvalidArgument)
+			}
+			for _, call := range handler.calls {
+				if call == "Login" {
+					t.Errorf("NodeStageVolume() logged into the iSCSI target, calls = %v", handler.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestBlockVolumeNodeDriver_NodeStageVolume_RawBlockUnsupported(t *testing.T) {
+	d := BlockVolumeNodeDriver{NodeDriver: NodeDriver{
+		logger:      zap.S(),
+		util:        &csi_util.Util{Logger: zap.S()},
+		volumeLocks: csi_util.NewVolumeLocks(),
+	}}
+	_, err := d.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+		VolumeId:          "ocid1.volume.oc1.phx.aaaa",
+		StagingTargetPath: t.TempDir(),
+		PublishContext: map[string]string{
+			attachmentType: attachmentTypeParavirtualized,
+		},
+		VolumeCapability: &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}},
+		},
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("NodeStageVolume() error = %v, want code %v", err, codes.FailedPrecondition)
+	}
+}
+
+func TestBlockVolumeNodeDriver_NodeStageVolume_StrictFsTypeValidation(t *testing.T) {
+	d := BlockVolumeNodeDriver{
+		NodeDriver: NodeDriver{
+			logger:      zap.S(),
+			util:        &csi_util.Util{Logger: zap.S()},
+			volumeLocks: csi_util.NewVolumeLocks(),
+		},
+		strictFsTypeValidation: true,
+	}
+	_, err := d.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+		VolumeId:          "ocid1.volume.oc1.phx.aaaa",
+		StagingTargetPath: t.TempDir(),
+		PublishContext: map[string]string{
+			attachmentType: attachmentTypeParavirtualized,
+		},
+		VolumeCapability: &csi.VolumeCapability{
+			AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{
+				FsType: "ex4",
+			}},
+		},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("NodeStageVolume() error = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
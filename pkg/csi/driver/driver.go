@@ -18,10 +18,7 @@ import (
 	"context"
 	"fmt"
 	"net"
-	"net/url"
 	"os"
-	"path"
-	"path/filepath"
 	"sync"
 	"time"
 
@@ -56,6 +53,21 @@ func init() {
 	LustreDriverName = getEnv("LUSTRE_VOLUME_DRIVER_NAME", "lustre.csi.oraclecloud.com")
 }
 
+// DefaultEndpointForDriver returns the conventional unix socket endpoint the
+// node plugin of the given driver listens on. Drivers other than the block
+// volume, FSS and Lustre drivers get a socket in a directory named after them.
+func DefaultEndpointForDriver(driverName string) string {
+	switch driverName {
+	case BlockVolumeDriverName:
+		return "unix://tmp/csi.sock"
+	case FSSDriverName:
+		return "unix://tmp/fss/csi.sock"
+	case LustreDriverName:
+		return "unix:///lustre/csi.sock"
+	}
+	return "unix://tmp/" + driverName + "/csi.sock"
+}
+
 func getEnv(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -141,11 +153,17 @@ type NodeDriver struct {
 // BlockVolumeNodeDriver extends NodeDriver
 type BlockVolumeNodeDriver struct {
 	NodeDriver
+	// rawBlockSupported is false when the node cannot map raw block volumes into pods
+	rawBlockSupported bool
+	// strictFsTypeValidation rejects unsupported fsTypes instead of defaulting to ext4
+	strictFsTypeValidation bool
 }
 
 // FSSNodeDriver extends NodeDriver
 type FSSNodeDriver struct {
 	NodeDriver
+	// requireFipsForInTransitEncryption rejects in-transit encrypted volumes on nodes without FIPS mode enabled
+	requireFipsForInTransitEncryption bool
 }
 
 type LustreNodeDriver struct {
@@ -234,12 +252,19 @@ func getMetricPusher(metricPusherGetter MetricPusherGetter, logger *zap.SugaredL
 	return metricPusher, nil
 }
 
-func GetNodeDriver(name string, nodeID string, nodeMetadata *csi_util.NodeMetadata, kubeClientSet kubernetes.Interface, logger *zap.SugaredLogger, csiConfig *csi_util.CSIConfig) csi.NodeServer {
+func GetNodeDriver(name string, nodeID string, nodeMetadata *csi_util.NodeMetadata, kubeClientSet kubernetes.Interface, logger *zap.SugaredLogger, csiConfig *csi_util.CSIConfig, strictFsTypeValidation, requireFipsForInTransitEncryption bool) csi.NodeServer {
 	if name == BlockVolumeDriverName {
-		return BlockVolumeNodeDriver{NodeDriver: newNodeDriver(nodeID, nodeMetadata, kubeClientSet, logger, csiConfig)}
+		return BlockVolumeNodeDriver{
+			NodeDriver:             newNodeDriver(nodeID, nodeMetadata, kubeClientSet, logger, csiConfig),
+			rawBlockSupported:      rawBlockSupported(logger),
+			strictFsTypeValidation: strictFsTypeValidation,
+		}
 	}
 	if name == FSSDriverName {
-		return FSSNodeDriver{NodeDriver: newNodeDriver(nodeID, nodeMetadata, kubeClientSet, logger, csiConfig)}
+		return FSSNodeDriver{
+			NodeDriver:                        newNodeDriver(nodeID, nodeMetadata, kubeClientSet, logger, csiConfig),
+			requireFipsForInTransitEncryption: requireFipsForInTransitEncryption,
+		}
 	}
 	if name == LustreDriverName {
 		return LustreNodeDriver{NodeDriver: newNodeDriver(nodeID, nodeMetadata, kubeClientSet, logger, csiConfig)}
@@ -247,18 +272,39 @@ func GetNodeDriver(name string, nodeID string, nodeMetadata *csi_util.NodeMetada
 	return nil
 }
 
+// rawBlockSupported probes the node for raw block volume support. Nodes that
+// can not be probed are assumed to support raw block volumes, as before the
+// probe existed.
+func rawBlockSupported(logger *zap.SugaredLogger) bool {
+	supported, err := csi_util.SupportsRawBlock()
+	if err != nil {
+		logger.With(zap.Error(err)).Warn("Failed to detect raw block volume support, assuming it is supported.")
+		return true
+	}
+	if !supported {
+		logger.Warn("Raw block volumes are not supported on this node.")
+	}
+	return supported
+}
+
 // NewNodeDriver creates a new CSI node driver for OCI blockvolume
 func NewNodeDriver(logger *zap.SugaredLogger, nodeOptions nodedriveroptions.NodeOptions) (*Driver, error) {
 	logger.With("endpoint", nodeOptions.Endpoint, "kubeconfig", nodeOptions.Kubeconfig, "master", nodeOptions.Master, "nodeID",
 		nodeOptions.NodeID).Info("Creating a new CSI Node driver.")
 
+	if err := csi_util.ValidateDriverName(nodeOptions.DriverName); err != nil {
+		return nil, err
+	}
+
 	kubeClientSet := csi_util.GetKubeClient(logger, nodeOptions.Master, nodeOptions.Kubeconfig)
 	nodeMetadata := &csi_util.NodeMetadata{}
 	csiConfig := &csi_util.CSIConfig{}
+	strictFsTypeValidation := csi_util.GetIsFeatureEnabledFromEnv(logger, strictFsTypeFeatureFlagName, false)
+	requireFipsForInTransitEncryption := csi_util.GetIsFeatureEnabledFromEnv(logger, requireFipsFeatureFlagName, false)
 
 	return &Driver{
 		controllerDriver:       nil,
-		nodeDriver:             GetNodeDriver(nodeOptions.DriverName, nodeOptions.NodeID, nodeMetadata, kubeClientSet, logger, csiConfig),
+		nodeDriver:             GetNodeDriver(nodeOptions.DriverName, nodeOptions.NodeID, nodeMetadata, kubeClientSet, logger, csiConfig, strictFsTypeValidation, requireFipsForInTransitEncryption),
 		endpoint:               nodeOptions.Endpoint,
 		logger:                 logger,
 		enableControllerServer: nodeOptions.EnableControllerServer,
@@ -273,6 +319,10 @@ func NewControllerDriver(logger *zap.SugaredLogger, driverConfig ControllerDrive
 	logger.With("endpoint", driverConfig.CsiEndpoint, "kubeconfig", driverConfig.CsiKubeConfig, "master",
 		driverConfig.CsiMaster).Info("Creating a new CSI Controller driver.")
 
+	if err := csi_util.ValidateDriverName(driverConfig.DriverName); err != nil {
+		return nil, err
+	}
+
 	kubeClientSet := csi_util.GetKubeClient(logger, driverConfig.CsiMaster, driverConfig.CsiKubeConfig)
 
 	cfg := getConfig(logger)
@@ -315,32 +365,21 @@ func (d *Driver) GetNodeDriver() csi.NodeServer {
 
 // Run starts a gRPC server on the given endpoint
 func (d *Driver) Run() error {
-	u, err := url.Parse(d.endpoint)
+	scheme, addr, err := csi_util.ParseEndpoint(d.endpoint)
 	if err != nil {
-		d.logger.With("endpoint", d.endpoint).With("Failed to parse address").Error(err)
-		return fmt.Errorf("failed to parse the address: %s", d.endpoint)
-	}
-
-	addr := path.Join(u.Host, filepath.FromSlash(u.Path))
-	if u.Host == "" {
-		addr = filepath.FromSlash(u.Path)
-	}
-
-	// CSI plugins talk only over UNIX sockets currently
-	if u.Scheme != "unix" {
-		d.logger.With("schema", u.Scheme).With("Currently only unix domain sockets are supported").Error(err)
-		return fmt.Errorf("currently only unix domain sockets are supported, have: %s", u.Scheme)
+		d.logger.With("endpoint", d.endpoint).With(zap.Error(err)).Error("Invalid endpoint.")
+		return err
 	}
 
 	// remove the socket if it's already there. This can happen if we
 	// deploy a new version and the socket was created from the old running plugin.
 	d.logger.With("address", addr).Info("Removing socket.")
-	if err := os.Remove(addr); err != nil && !os.IsNotExist(err) {
+	if err := csi_util.RemoveStaleSocket(d.endpoint); err != nil {
 		d.logger.With("address", addr).With("Failed to remove unix domain socket file").Error(err)
 		return fmt.Errorf("failed to remove unix domain socket file %s", addr)
 	}
 
-	listener, err := net.Listen(u.Scheme, addr)
+	listener, err := net.Listen(scheme, addr)
 	if err != nil {
 		d.logger.With("address", addr).With("msg", "Failed to listen").Error(err)
 		return fmt.Errorf("failed to listen")
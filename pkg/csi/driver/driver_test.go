@@ -70,3 +70,34 @@ func getMetricPusherSuccess(logger *zap.SugaredLogger) (*metrics.MetricPusher, e
 func getMetricPusherFailure(logger *zap.SugaredLogger) (*metrics.MetricPusher, error) {
 	return nil, fmt.Errorf("failed to get metric pusher")
 }
+
+func Test_DefaultEndpointForDriver(t *testing.T) {
+	tests := map[string]struct {
+		driverName string
+		want       string
+	}{
+		"Block volume driver": {
+			driverName: BlockVolumeDriverName,
+			want:       "unix://tmp/csi.sock",
+		},
+		"FSS driver": {
+			driverName: FSSDriverName,
+			want:       "unix://tmp/fss/csi.sock",
+		},
+		"Lustre driver": {
+			driverName: LustreDriverName,
+			want:       "unix:///lustre/csi.sock",
+		},
+		"Unknown driver": {
+			driverName: "example.csi.oraclecloud.com",
+			want:       "unix://tmp/example.csi.oraclecloud.com/csi.sock",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := DefaultEndpointForDriver(tt.driverName); got != tt.want {
+				t.Errorf("DefaultEndpointForDriver() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -226,7 +226,11 @@ func (d *FSSControllerDriver) CreateVolume(ctx context.Context, req *csi.CreateV
 			CapacityBytes: 0,
 
 			VolumeContext: map[string]string{
-				"encryptInTransit": storageClassParameters.encryptInTransit,
+				csi_util.VolumeContextEncryptInTransitKey:    storageClassParameters.encryptInTransit,
+				csi_util.FssFilesystemOcidKey:                filesystemOCID,
+				csi_util.FssMountTargetIpKey:                 csi_util.FormatValidIp(mountTargetIp),
+				csi_util.FssExportPathKey:                    storageClassParameters.exportPath,
+				csi_util.FssMountTargetAvailabilityDomainKey: storageClassParameters.availabilityDomain,
 			},
 		},
 	}, nil
@@ -248,20 +252,8 @@ func extractSecretParameters(log *zap.SugaredLogger, parameters map[string]strin
 }
 
 func checkForSupportedVolumeCapabilities(volumeCaps []*csi.VolumeCapability) error {
-	hasSupport := func(cap *csi.VolumeCapability) error {
-		if blk := cap.GetBlock(); blk != nil {
-			return fmt.Errorf("driver does not support block volumes")
-		}
-		for _, c := range fssSupportedVolumeCapabilities {
-			if c.GetMode() == cap.AccessMode.GetMode() {
-				return nil
-			}
-		}
-		return fmt.Errorf("driver does not support access mode %v", cap.AccessMode.GetMode())
-	}
-
 	for _, c := range volumeCaps {
-		if err := hasSupport(c); err != nil {
+		if err := ValidateAccessModeForDriver(FSSDriverName, c); err != nil {
 			return err
 		}
 	}
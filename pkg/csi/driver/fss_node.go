@@ -39,8 +39,14 @@ const (
 	FipsEnabled                = "1"
 	fssMountSemaphoreTimeout   = time.Second * 30
 	fssUnmountSemaphoreTimeout = time.Second * 30
+
+	// requireFipsFeatureFlagName enables rejecting in-transit encrypted volumes on nodes without FIPS mode enabled
+	requireFipsFeatureFlagName = "CSI_FSS_REQUIRE_FIPS"
 )
 
+// enforceFipsForEncryptedVolume is overridden in tests to fake the FIPS mode of the node.
+var enforceFipsForEncryptedVolume = csi_util.EnforceFipsForEncryptedVolume
+
 var fssMountSemaphore = semaphore.NewWeighted(int64(2))
 var fssUnmountSemaphore = semaphore.NewWeighted(int64(4))
 
@@ -69,10 +75,8 @@ func (d FSSNodeDriver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVo
 		d.util.LoadNodeMetadataFromApiServer(ctx, d.KubeClient, d.nodeID, d.nodeMetadata)
 	}
 
-	if csi_util.IsIpv4(mountTargetIP) && !d.nodeMetadata.Ipv4Enabled {
-		return nil, status.Error(codes.InvalidArgument, "Ipv4 mount target identified in volume id, but worker node does not support ipv4 ip family.")
-	} else if csi_util.IsIpv6(mountTargetIP) && !d.nodeMetadata.Ipv6Enabled {
-		return nil, status.Error(codes.InvalidArgument, "Ipv6 mount target identified in volume id, but worker node does not support ipv6 ip family.")
+	if err := csi_util.ValidateMountTargetFamily(mountTargetIP, d.nodeMetadata); err != nil {
+		return nil, err
 	}
 
 	logger.Debugf("volume context: %v", req.VolumeContext)
@@ -94,6 +98,17 @@ func (d FSSNodeDriver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVo
 		return nil, status.Errorf(codes.InvalidArgument, "EncryptInTransit must be a boolean value")
 	}
 
+	if err := csi_util.ValidateEncryptionWithIpFamily(encryptInTransit, d.nodeMetadata); err != nil {
+		return nil, err
+	}
+
+	if d.requireFipsForInTransitEncryption {
+		if err := enforceFipsForEncryptedVolume(encryptInTransit); err != nil {
+			logger.With(zap.Error(err)).Error("FIPS mode is required for in-transit encrypted volumes.")
+			return nil, err
+		}
+	}
+
 	mounter := mount.New("")
 
 	if encryptInTransit {
@@ -192,7 +207,7 @@ func (d FSSNodeDriver) NodeStageVolume(ctx context.Context, req *csi.NodeStageVo
 
 func isInTransitEncryptionEnabled(volumeContext map[string]string) (bool, error) {
 	if volumeContext != nil {
-		if encryptInTransit, ok := volumeContext["encryptInTransit"]; ok {
+		if encryptInTransit, ok := volumeContext[csi_util.VolumeContextEncryptInTransitKey]; ok {
 			return strconv.ParseBool(encryptInTransit)
 		}
 	}
@@ -221,6 +236,10 @@ func (d FSSNodeDriver) NodePublishVolume(ctx context.Context, req *csi.NodePubli
 		return nil, status.Error(codes.InvalidArgument, "Target Path must be provided")
 	}
 
+	if err := csi_util.ValidateDistinctPaths(req.StagingTargetPath, req.TargetPath); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	logger := d.logger.With("volumeID", req.VolumeId)
 	logger.Debugf("volume context: %v", req.VolumeContext)
 
@@ -489,14 +508,19 @@ func (d FSSNodeDriver) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoRequ
 
 	d.logger.With("nodeId", d.nodeID, "availableDomain", d.nodeMetadata.AvailabilityDomain).Info("Available domain of node identified.")
 
+	segments := map[string]string{
+		kubeAPI.LabelZoneFailureDomain: d.nodeMetadata.AvailabilityDomain,
+		kubeAPI.LabelTopologyZone:      d.nodeMetadata.AvailabilityDomain,
+	}
+	if d.nodeMetadata.Region != "" {
+		segments[kubeAPI.LabelTopologyRegion] = d.nodeMetadata.Region
+	}
+
 	return &csi.NodeGetInfoResponse{
 		NodeId: d.nodeID,
 		// make sure that the driver works on this particular AD only
 		AccessibleTopology: &csi.Topology{
-			Segments: map[string]string{
-				kubeAPI.LabelZoneFailureDomain: d.nodeMetadata.AvailabilityDomain,
-				kubeAPI.LabelTopologyZone:      d.nodeMetadata.AvailabilityDomain,
-			},
+			Segments: segments,
 		},
 	}, nil
 }
@@ -0,0 +1,159 @@
+// Copyright 2025 Oracle and/or its affiliates. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package driver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	kubeAPI "k8s.io/api/core/v1"
+
+	csi_util "github.com/oracle/oci-cloud-controller-manager/pkg/csi-util"
+)
+
+func TestFSSNodeDriver_NodeStageVolume_RequireFips(t *testing.T) {
+	const volumeID = "ocid1.filesystem.oc1.phx.aaaaaaaaaahjpdudobuhqllqojxwiotqnb4c2ylefuzaaaaa:10.0.2.44:/FileSystem-Test"
+	defer func(enforce func(bool) error) {
+		enforceFipsForEncryptedVolume = enforce
+	}(enforceFipsForEncryptedVolume)
+
+	nonFipsNode := func(encrypted bool) error {
+		if encrypted {
+			return status.Error(codes.FailedPrecondition, "FIPS mode must be enabled on the node for in-transit encrypted volumes")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name         string
+		requireFips  bool
+		encrypted    string
+		wantCode     codes.Code
+		wantEnforced bool
+	}{
+		{
+			name:         "Encrypted volume on a non-FIPS node when FIPS is required",
+			requireFips:  true,
+			encrypted:    "true",
+			wantCode:     codes.FailedPrecondition,
+			wantEnforced: true,
+		},
+		{
+			name:         "Unencrypted volume on a non-FIPS node when FIPS is required",
+			requireFips:  true,
+			encrypted:    "false",
+			wantCode:     codes.Aborted,
+			wantEnforced: true,
+		},
+		{
+			name:        "FIPS is not required",
+			requireFips: false,
+			encrypted:   "false",
+			wantCode:    codes.Aborted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enforced := false
+			enforceFipsForEncryptedVolume = func(encrypted bool) error {
+				enforced = true
+				return nonFipsNode(encrypted)
+			}
+
+			d := FSSNodeDriver{
+				NodeDriver: NodeDriver{
+					logger:       zap.S(),
+					util:         &csi_util.Util{Logger: zap.S()},
+					volumeLocks:  csi_util.NewVolumeLocks(),
+					nodeMetadata: &csi_util.NodeMetadata{IsNodeMetadataLoaded: true, Ipv4Enabled: true},
+				},
+				requireFipsForInTransitEncryption: tt.requireFips,
+			}
+			// Holding the volume lock stops staging before anything is mounted.
+			d.volumeLocks.TryAcquire(volumeID)
+
+			_, err := d.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+				VolumeId:          volumeID,
+				StagingTargetPath: t.TempDir(),
+				VolumeCapability: &csi.VolumeCapability{
+					AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}},
+				},
+				VolumeContext: map[string]string{csi_util.VolumeContextEncryptInTransitKey: tt.encrypted},
+			})
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("NodeStageVolume() error = %v, want code %v", err, tt.wantCode)
+			}
+			if enforced != tt.wantEnforced {
+				t.Errorf("NodeStageVolume() enforced FIPS = %v, want %v", enforced, tt.wantEnforced)
+			}
+		})
+	}
+}
+
+func TestFSSNodeDriver_NodeGetInfo(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodeMetadata *csi_util.NodeMetadata
+		want         map[string]string
+	}{
+		{
+			name: "Node with region",
+			nodeMetadata: &csi_util.NodeMetadata{
+				IsNodeMetadataLoaded: true,
+				AvailabilityDomain:   "PHX-AD-1",
+				Region:               "us-phoenix-1",
+			},
+			want: map[string]string{
+				kubeAPI.LabelZoneFailureDomain: "PHX-AD-1",
+				kubeAPI.LabelTopologyZone:      "PHX-AD-1",
+				kubeAPI.LabelTopologyRegion:    "us-phoenix-1",
+			},
+		},
+		{
+			name: "Node without region",
+			nodeMetadata: &csi_util.NodeMetadata{
+				IsNodeMetadataLoaded: true,
+				AvailabilityDomain:   "PHX-AD-1",
+			},
+			want: map[string]string{
+				kubeAPI.LabelZoneFailureDomain: "PHX-AD-1",
+				kubeAPI.LabelTopologyZone:      "PHX-AD-1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := FSSNodeDriver{
+				NodeDriver: NodeDriver{
+					nodeID:       "node1",
+					logger:       zap.S(),
+					nodeMetadata: tt.nodeMetadata,
+				},
+			}
+			got, err := d.NodeGetInfo(context.Background(), &csi.NodeGetInfoRequest{})
+			if err != nil {
+				t.Fatalf("NodeGetInfo() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.AccessibleTopology.Segments, tt.want) {
+				t.Errorf("NodeGetInfo() segments = %v, want %v", got.AccessibleTopology.Segments, tt.want)
+			}
+		})
+	}
+}
@@ -25,6 +25,9 @@ import (
 	lumberjack "gopkg.in/natefinch/lumberjack.v2"
 )
 
+// LogLevelKey is the viper key holding the configured zap log level.
+const LogLevelKey = "log-level"
+
 var (
 	lvl         = zapcore.InfoLevel
 	logJSON     = false
@@ -116,12 +119,34 @@ func logger(path string) *zap.Logger {
 }
 
 func setFlags() {
-	logLvl := viper.GetInt("log-level")
 	logJSON = viper.GetBool("log-json")
-	lvl = zapcore.Level(logLvl)
+	lvl = GetConfiguredLogLevel()
 	logfilePath = viper.GetString("logfile-path")
 }
 
+// GetConfiguredLogLevel returns the log level stored in viper under
+// LogLevelKey. The value may be a zap level number or name; anything unset or
+// invalid falls back to info.
+func GetConfiguredLogLevel() zapcore.Level {
+	if !viper.IsSet(LogLevelKey) {
+		return zapcore.InfoLevel
+	}
+
+	var level zapcore.Level
+	if name, ok := viper.Get(LogLevelKey).(string); ok {
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			return zapcore.InfoLevel
+		}
+	} else {
+		level = zapcore.Level(viper.GetInt(LogLevelKey))
+	}
+
+	if level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+		return zapcore.InfoLevel
+	}
+	return level
+}
+
 // FieldsFromEnv extracts log fields from environment variables.
 // If an environment variable starts with LOG_FIELD_, the suffix is extracted
 // and split on =. The first part is used for the name and the second for the
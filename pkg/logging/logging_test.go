@@ -18,6 +18,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -79,3 +80,48 @@ func TestFieldsFromEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestGetConfiguredLogLevel(t *testing.T) {
+	testCases := map[string]struct {
+		value interface{}
+		level zapcore.Level
+	}{
+		"unset": {
+			value: nil,
+			level: zapcore.InfoLevel,
+		},
+		"debug_as_int8": {
+			value: int8(zapcore.DebugLevel),
+			level: zapcore.DebugLevel,
+		},
+		"error_as_int": {
+			value: int(zapcore.ErrorLevel),
+			level: zapcore.ErrorLevel,
+		},
+		"warn_as_name": {
+			value: "warn",
+			level: zapcore.WarnLevel,
+		},
+		"unknown_name": {
+			value: "infoo",
+			level: zapcore.InfoLevel,
+		},
+		"out_of_range": {
+			value: 42,
+			level: zapcore.InfoLevel,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			if tc.value != nil {
+				viper.Set(LogLevelKey, tc.value)
+			}
+			if level := GetConfiguredLogLevel(); level != tc.level {
+				t.Errorf("Got incorrect level: expected=%v actual=%v", tc.level, level)
+			}
+		})
+	}
+}
@@ -35,6 +35,10 @@ const (
 	GiB
 	// TiB is 1024 GB
 	TiB
+	// PiB is 1024 TB
+	PiB
+	// EiB is 1024 PB
+	EiB
 )
 
 const (
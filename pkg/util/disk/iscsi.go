@@ -47,16 +47,26 @@ const (
 	// ISCSIIP is the map key to get or save iSCSI IP
 	ISCSIIP = "iscsi_ip"
 	// ISCSIPORT is the map key to get or save iSCSI Port
-	ISCSIPORT         = "iscsi_port"
+	ISCSIPORT = "iscsi_port"
+	// SCSIWWID is the map key to get or save the SCSI WWID of the volume
+	SCSIWWID          = "scsi_wwid"
 	loginPollInterval = 5 * time.Second
 	pathPollInterval  = 2 * time.Second
 	pathPollTimeout   = 3 * time.Minute
 	waitForPathDelay  = 1 * time.Second
 
+	// iscsiadmErrNoObjsFound is the exit status of iscsiadm when no session
+	// or node record matches the request.
+	iscsiadmErrNoObjsFound = 21
+
 	LIST_PATHS_COMMAND  = "ls -f /dev/disk/by-path"
 	DISK_BY_PATH_FOLDER = "/dev/disk/by-path/"
 )
 
+// nodeStartupPattern matches the node.startup setting of an iSCSI node record
+// as printed by `iscsiadm -m node -T <IQN> -p <ip>:<port>`.
+var nodeStartupPattern = regexp.MustCompile(`(?m)^node\.startup\s*=\s*(\S+)\s*$`)
+
 // ErrMountPointNotFound is returned when a given path does not appear to be
 // a mount point.
 var ErrMountPointNotFound = errors.New("mount point not found")
@@ -150,6 +160,22 @@ func (sd *Disk) Target() string {
 	return fmt.Sprintf("[%s]:%d", sd.IscsiIp, sd.Port)
 }
 
+// SameTarget returns true if both disks refer to the same iSCSI target. An
+// IPv4 address and its IPv4-mapped IPv6 form are treated as equal.
+func (sd *Disk) SameTarget(other *Disk) bool {
+	if sd == nil || other == nil {
+		return sd == other
+	}
+	if sd.IQN != other.IQN || sd.Port != other.Port {
+		return false
+	}
+	ip, otherIp := net.ParseIP(sd.IscsiIp), net.ParseIP(other.IscsiIp)
+	if ip == nil || otherIp == nil {
+		return sd.IscsiIp == other.IscsiIp
+	}
+	return ip.Equal(otherIp)
+}
+
 func newWithMounter(logger *zap.SugaredLogger, mounter mount.Interface, iqn, iSCSIIp string, port int) Interface {
 	return &iSCSIMounter{
 		disk: &Disk{
@@ -339,6 +365,13 @@ func (c *iSCSIMounter) iscsiadm(parts ...string) (string, error) {
 	return string(output), nil
 }
 
+// isNoObjectsFound returns true if iscsiadm failed because the session or node
+// record it was asked to act on does not exist.
+func isNoObjectsFound(err error) bool {
+	var exitErr exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitStatus() == iscsiadmErrNoObjsFound
+}
+
 func (c *iSCSIMounter) AddToDB() error {
 	c.logger.With("IQN", c.disk.IQN, "target", c.disk.Target()).Info("Adding node record to db.")
 
@@ -374,6 +407,54 @@ func (c *iSCSIMounter) SetAutomaticLogin() error {
 	return nil
 }
 
+// SetISCSINodeStartupManual sets node.startup to manual on the node record of
+// the given disk, so the node only logs in to the target when asked to. The
+// record is left untouched when it is already set to manual.
+func SetISCSINodeStartupManual(d *Disk) error {
+	if d == nil {
+		return errors.New("iscsi: disk must be provided to update its node record")
+	}
+	c := &iSCSIMounter{
+		disk:   d,
+		runner: exec.New(),
+		logger: zap.NewNop().Sugar(),
+	}
+	return c.setManualLogin()
+}
+
+// setManualLogin sets node.startup to manual on the node record of the target.
+// sudo iscsiadm -m node -T <IQN> -p <ip>:<port> -o update -n node.startup -v manual
+func (c *iSCSIMounter) setManualLogin() error {
+	record, err := c.iscsiadm(
+		"-m", "node",
+		"-T", c.disk.IQN,
+		"-p", c.disk.Target())
+	if err != nil {
+		return fmt.Errorf("iscsi: error reading node record: %v", err)
+	}
+	if m := nodeStartupPattern.FindStringSubmatch(record); m != nil && m[1] == "manual" {
+		c.logger.With("IQN", c.disk.IQN, "target", c.disk.Target()).Info("Manual node login already configured.")
+		return nil
+	}
+
+	c.logger.With("IQN", c.disk.IQN, "target", c.disk.Target()).Info("Configuring manual node login.")
+
+	_, err = c.iscsiadm(
+		"-m", "node",
+		"-o", "update",
+		"-T", c.disk.IQN,
+		"-p", c.disk.Target(),
+		"-n", "node.startup",
+		"-v", "manual")
+	if err != nil {
+		return fmt.Errorf("iscsi: error configuring manual node login: %v", err)
+	}
+
+	c.logger.With("IQN", c.disk.IQN, "target", c.disk.Target()).Info("Configured manual node login.")
+
+	return nil
+}
+
 func (c *iSCSIMounter) Login() error {
 	c.logger.With("IQN", c.disk.IQN, "target", c.disk.Target()).Info("Logging in.")
 
@@ -391,7 +472,7 @@ func (c *iSCSIMounter) Login() error {
 	return nil
 }
 
-// Logout logs out the iSCSI target.
+// Logout logs out the iSCSI target. It is a no-op when there is no session.
 // sudo iscsiadm -m node -T <IQN> -p <ip>:<port>  -u
 func (c *iSCSIMounter) Logout() error {
 	c.logger.With("IQN", c.disk.IQN, "target", c.disk.Target()).Info("Logging out.")
@@ -400,6 +481,10 @@ func (c *iSCSIMounter) Logout() error {
 		"-T", c.disk.IQN,
 		"-p", c.disk.Target(),
 		"-u")
+	if isNoObjectsFound(err) {
+		c.logger.With("IQN", c.disk.IQN, "target", c.disk.Target()).Info("No session found, already logged out.")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("iscsi: error logging out target: %v", err)
 	}
@@ -428,6 +513,9 @@ func (c *iSCSIMounter) UpdateQueueDepth() error {
 	return nil
 }
 
+// RemoveFromDB deletes the node record of the iSCSI target so that the node
+// does not log back in after a reboot. It is a no-op when there is no record.
+// sudo iscsiadm -m node -o delete -T <IQN> -p <ip>:<port>
 func (c *iSCSIMounter) RemoveFromDB() error {
 	c.logger.With("IQN", c.disk.IQN, "target", c.disk.Target()).Info("Removing from database.")
 	_, err := c.iscsiadm(
@@ -435,6 +523,10 @@ func (c *iSCSIMounter) RemoveFromDB() error {
 		"-o", "delete",
 		"-T", c.disk.IQN,
 		"-p", c.disk.Target())
+	if isNoObjectsFound(err) {
+		c.logger.With("IQN", c.disk.IQN, "target", c.disk.Target()).Info("No node record found, already removed from database.")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("iscsi: error removing target from database: %v", err)
 	}
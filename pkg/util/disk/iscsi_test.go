@@ -15,12 +15,62 @@
 package disk
 
 import (
+	"context"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
+	"go.uber.org/zap"
 	"k8s.io/mount-utils"
+	"k8s.io/utils/exec"
 )
 
+// fakeExec replies to commands with canned output keyed by their arguments
+// and records the arguments of every command it was asked to run.
+type fakeExec struct {
+	outputs map[string]string
+	errs    map[string]error
+	calls   []string
+}
+
+func (f *fakeExec) Command(cmd string, args ...string) exec.Cmd {
+	line := strings.Join(args, " ")
+	f.calls = append(f.calls, line)
+	return &fakeCmd{output: f.outputs[line], err: f.errs[line]}
+}
+
+func (f *fakeExec) CommandContext(ctx context.Context, cmd string, args ...string) exec.Cmd {
+	return f.Command(cmd, args...)
+}
+
+func (f *fakeExec) LookPath(file string) (string, error) {
+	return "/sbin/" + file, nil
+}
+
+type fakeCmd struct {
+	exec.Cmd
+	output string
+	err    error
+}
+
+func (c *fakeCmd) Output() ([]byte, error) {
+	return []byte(c.output), c.err
+}
+
+func (c *fakeCmd) CombinedOutput() ([]byte, error) {
+	return []byte(c.output), c.err
+}
+
+func newFakeISCSIMounter(runner *fakeExec) *iSCSIMounter {
+	return &iSCSIMounter{
+		disk:    &Disk{IQN: "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca", IscsiIp: "169.254.2.2", Port: 3260},
+		runner:  runner,
+		mounter: mount.NewFakeMounter(nil),
+		logger:  zap.S(),
+	}
+}
+
 func TestGetMountPointForPath(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -70,3 +120,113 @@ func TestGetMountPointForPath(t *testing.T) {
 		})
 	}
 }
+
+func TestDiskSameTarget(t *testing.T) {
+	const iqn = "iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca"
+	testCases := []struct {
+		name     string
+		disk     *Disk
+		other    *Disk
+		expected bool
+	}{
+		{
+			name:     "equal ipv4",
+			disk:     &Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			other:    &Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			expected: true,
+		}, {
+			name:     "equal ipv6 in different notation",
+			disk:     &Disk{IQN: iqn, IscsiIp: "fd00:c1::a9fe:202", Port: 3260},
+			other:    &Disk{IQN: iqn, IscsiIp: "fd00:00c1:0000::a9fe:0202", Port: 3260},
+			expected: true,
+		}, {
+			name:     "ipv4 and its ipv6-mapped form",
+			disk:     &Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			other:    &Disk{IQN: iqn, IscsiIp: "::ffff:169.254.2.2", Port: 3260},
+			expected: true,
+		}, {
+			name:     "different ip",
+			disk:     &Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			other:    &Disk{IQN: iqn, IscsiIp: "169.254.2.3", Port: 3260},
+			expected: false,
+		}, {
+			name:     "different port",
+			disk:     &Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			other:    &Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3261},
+			expected: false,
+		}, {
+			name:     "different iqn",
+			disk:     &Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			other:    &Disk{IQN: "iqn.2015-12.com.oracleiaas:other", IscsiIp: "169.254.2.2", Port: 3260},
+			expected: false,
+		}, {
+			name:     "nil other",
+			disk:     &Disk{IQN: iqn, IscsiIp: "169.254.2.2", Port: 3260},
+			expected: false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.disk.SameTarget(tt.other); result != tt.expected {
+				t.Errorf("SameTarget() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetISCSINodeStartupManual(t *testing.T) {
+	const (
+		showCmd   = "-m node -T iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca -p 169.254.2.2:3260"
+		updateCmd = "-m node -o update -T iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca -p 169.254.2.2:3260 -n node.startup -v manual"
+	)
+	testCases := []struct {
+		name          string
+		runner        *fakeExec
+		expectedCalls []string
+		expectErr     bool
+	}{
+		{
+			name: "automatic startup is updated to manual",
+			runner: &fakeExec{outputs: map[string]string{
+				showCmd: "node.name = iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca\nnode.startup = automatic\n",
+			}},
+			expectedCalls: []string{showCmd, updateCmd},
+		}, {
+			name: "manual startup is left untouched",
+			runner: &fakeExec{outputs: map[string]string{
+				showCmd: "node.name = iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca\nnode.startup = manual\n",
+			}},
+			expectedCalls: []string{showCmd},
+		}, {
+			name:          "missing node record",
+			runner:        &fakeExec{errs: map[string]error{showCmd: exec.CodeExitError{Err: errors.New("exit status 21"), Code: 21}}},
+			expectedCalls: []string{showCmd},
+			expectErr:     true,
+		}, {
+			name: "update fails",
+			runner: &fakeExec{
+				outputs: map[string]string{showCmd: "node.startup = automatic\n"},
+				errs:    map[string]error{updateCmd: exec.CodeExitError{Err: errors.New("exit status 1"), Code: 1}},
+			},
+			expectedCalls: []string{showCmd, updateCmd},
+			expectErr:     true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newFakeISCSIMounter(tt.runner).setManualLogin()
+			if (err != nil) != tt.expectErr {
+				t.Errorf("setManualLogin() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if !reflect.DeepEqual(tt.runner.calls, tt.expectedCalls) {
+				t.Errorf("setManualLogin() ran %v, expected %v", tt.runner.calls, tt.expectedCalls)
+			}
+		})
+	}
+
+	if err := SetISCSINodeStartupManual(nil); err == nil {
+		t.Errorf("SetISCSINodeStartupManual(nil) error = nil, expected an error")
+	}
+}
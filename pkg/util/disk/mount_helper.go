@@ -123,6 +123,27 @@ func UnmountPath(logger *zap.SugaredLogger, mountPath string, mounter mount.Inte
 	return fmt.Errorf("Failed to unmount path %v", mountPath)
 }
 
+// CleanupStaging tears down the staging of a volume in the order
+// NodeUnstageVolume needs: unmount the staging path, log out of the iSCSI
+// target and delete its node record. Each step tolerates having already been
+// done, so a partially cleaned up volume can be unstaged again. Handlers that
+// do not log in to a target treat the last two steps as no-ops.
+func CleanupStaging(logger *zap.SugaredLogger, handler Interface, stagingPath string) error {
+	if err := handler.UnmountPath(stagingPath); err != nil {
+		logger.With(zap.Error(err), "stagingPath", stagingPath).Error("failed to unmount the staging path")
+		return err
+	}
+	if err := handler.Logout(); err != nil {
+		logger.With(zap.Error(err)).Error("failed to logout from the iSCSI target")
+		return err
+	}
+	if err := handler.RemoveFromDB(); err != nil {
+		logger.With(zap.Error(err)).Error("failed to remove the iSCSI node record")
+		return err
+	}
+	return nil
+}
+
 func WaitForDirectoryDeletion(logger *zap.SugaredLogger, mountPath string) error {
 	var err error
 	// Try removing the mount path thrice, else suppress the error
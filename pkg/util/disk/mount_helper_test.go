@@ -1,8 +1,15 @@
 package disk
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"go.uber.org/zap"
+	"k8s.io/mount-utils"
+	"k8s.io/utils/exec"
 )
 
 func TestMakeMountArgs(t *testing.T) {
@@ -47,3 +54,80 @@ func TestMakeMountArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanupStaging(t *testing.T) {
+	const (
+		logoutCmd = "-m node -T iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca -p 169.254.2.2:3260 -u"
+		deleteCmd = "-m node -o delete -T iqn.2015-12.com.oracleiaas:63a2e76c-5353-4a75-82d0-ee31a39471ca -p 169.254.2.2:3260"
+	)
+	testCases := []struct {
+		name          string
+		mounted       bool
+		missing       bool
+		runner        *fakeExec
+		expectedCalls []string
+		expectErr     bool
+	}{
+		{
+			name:          "mounted staging path",
+			mounted:       true,
+			runner:        &fakeExec{},
+			expectedCalls: []string{logoutCmd, deleteCmd},
+		}, {
+			name:          "staging path already unmounted",
+			runner:        &fakeExec{},
+			expectedCalls: []string{logoutCmd, deleteCmd},
+		}, {
+			name:          "staging path already removed",
+			missing:       true,
+			runner:        &fakeExec{},
+			expectedCalls: []string{logoutCmd, deleteCmd},
+		}, {
+			name:    "already logged out and removed from database",
+			mounted: true,
+			runner: &fakeExec{errs: map[string]error{
+				logoutCmd: exec.CodeExitError{Err: errors.New("exit status 21"), Code: iscsiadmErrNoObjsFound},
+				deleteCmd: exec.CodeExitError{Err: errors.New("exit status 21"), Code: iscsiadmErrNoObjsFound},
+			}},
+			expectedCalls: []string{logoutCmd, deleteCmd},
+		}, {
+			name:          "logout fails before the node record is deleted",
+			mounted:       true,
+			runner:        &fakeExec{errs: map[string]error{logoutCmd: exec.CodeExitError{Err: errors.New("exit status 8"), Code: 8}}},
+			expectedCalls: []string{logoutCmd},
+			expectErr:     true,
+		}, {
+			name:          "node record deletion fails",
+			runner:        &fakeExec{errs: map[string]error{deleteCmd: exec.CodeExitError{Err: errors.New("exit status 1"), Code: 1}}},
+			expectedCalls: []string{logoutCmd, deleteCmd},
+			expectErr:     true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			stagingPath := filepath.Join(t.TempDir(), "staging")
+			if !tt.missing {
+				if err := os.Mkdir(stagingPath, 0750); err != nil {
+					t.Fatalf("failed to create %s: %v", stagingPath, err)
+				}
+			}
+			handler := newFakeISCSIMounter(tt.runner)
+			fakeMounter := handler.mounter.(*mount.FakeMounter)
+			if tt.mounted {
+				fakeMounter.MountPoints = []mount.MountPoint{{Device: "/dev/sdb", Path: stagingPath}}
+			}
+
+			err := CleanupStaging(zap.S(), handler, stagingPath)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("CleanupStaging() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if !reflect.DeepEqual(tt.runner.calls, tt.expectedCalls) {
+				t.Errorf("CleanupStaging() ran %v, expected %v", tt.runner.calls, tt.expectedCalls)
+			}
+			if len(fakeMounter.MountPoints) != 0 {
+				t.Errorf("CleanupStaging() left %v mounted", fakeMounter.MountPoints)
+			}
+		})
+	}
+}